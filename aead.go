@@ -0,0 +1,199 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+)
+
+// AuthError 认证失败, 可通过 errors.Is(err, ErrAuthFailed) 判断
+type AuthError struct {
+	Mode string
+}
+
+func (e *AuthError) Error() string {
+	return e.Mode + ": " + ErrAuthFailed.Error()
+}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuthFailed
+}
+
+type IAead interface {
+	IEncrypt
+	Additional(data ...[]byte) IAead
+	TagSize(size int) IAead
+	NonceSize(size int) IAead
+	FixedNonce() IAead
+	TryTagSize(size int) (IAead, error)
+	TryNonceSize(size int) (IAead, error)
+	TryFixedNonce() (IAead, error)
+}
+
+type aeadFactory func(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error)
+
+// componentAead 关联数据由多个独立分量组成 (如 SIV)
+type componentAead interface {
+	sealComponents(dst, nonce, plaintext []byte, additional [][]byte) []byte
+	openComponents(dst, nonce, ciphertext []byte, additional [][]byte) ([]byte, error)
+}
+
+// Aead 认证加密模式, 随机 nonce 时作为密文前缀, 固定 nonce 时取 iv 的前 nonceSize 个字节
+type Aead struct {
+	Method
+	name       string
+	factory    aeadFactory
+	nonceSize  int
+	tagSize    int
+	additional [][]byte
+}
+
+func newAead(m *Method, name string, factory aeadFactory, nonceSize, tagSize int) (IAead, error) {
+	a := &Aead{
+		Method:    *m,
+		name:      name,
+		factory:   factory,
+		nonceSize: nonceSize,
+		tagSize:   tagSize,
+	}
+
+	if err := a.build(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Additional 设置关联数据, 不支持多分量的模式会按顺序拼接
+func (a *Aead) Additional(data ...[]byte) IAead {
+	c := *a
+	c.additional = append([][]byte(nil), data...)
+	if err := c.build(); err != nil {
+		panic(err)
+	}
+
+	return &c
+}
+
+func (a *Aead) TagSize(size int) IAead {
+	return must(a.TryTagSize(size))
+}
+
+func (a *Aead) NonceSize(size int) IAead {
+	return must(a.TryNonceSize(size))
+}
+
+// FixedNonce 使用创建时 iv 的前 NonceSize 个字节作为固定 nonce, 密文不带前缀.
+// 同一个 key 下每次加密都使用同一个 nonce, GCM 等模式会泄露明文的异或并可伪造 tag,
+// 只用于测试向量或对方固定 nonce 且每个 key 只加密一次的场景
+func (a *Aead) FixedNonce() IAead {
+	return must(a.TryFixedNonce())
+}
+
+func (a *Aead) TryTagSize(size int) (IAead, error) {
+	c := *a
+	c.tagSize = size
+	if err := c.build(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (a *Aead) TryNonceSize(size int) (IAead, error) {
+	c := *a
+	c.nonceSize = size
+	if err := c.build(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (a *Aead) TryFixedNonce() (IAead, error) {
+	c := *a
+	c.randomIv = false
+	if err := c.build(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (a *Aead) build() error {
+	if !a.randomIv && len(a.iv) < a.nonceSize {
+		return &IvError{Algorithm: a.label(a.name), Size: len(a.iv), Expected: a.nonceSize}
+	}
+
+	aead, err := a.factory(a.block, a.nonceSize, a.tagSize)
+	if err != nil {
+		return err
+	}
+
+	name, nonceSize, additional := a.name, a.nonceSize, a.additional
+	a.encryptor = a.withIv(nonceSize, func(nonce []byte) IEncryptor {
+		return newAeadEncryptor(name, aead, nonce[:nonceSize], additional)
+	})
+	return nil
+}
+
+type aeadEncryptor struct {
+	name       string
+	aead       cipher.AEAD
+	nonce      []byte
+	additional [][]byte
+	joined     []byte
+}
+
+func newAeadEncryptor(name string, aead cipher.AEAD, nonce []byte, additional [][]byte) *aeadEncryptor {
+	return &aeadEncryptor{
+		name:       name,
+		aead:       aead,
+		nonce:      nonce,
+		additional: additional,
+		joined:     bytes.Join(additional, nil),
+	}
+}
+
+func (a aeadEncryptor) Encrypt(src []byte) (dst []byte, err error) {
+	if c, ok := a.aead.(componentAead); ok {
+		return c.sealComponents(nil, a.nonce, src, a.additional), nil
+	}
+
+	return a.aead.Seal(nil, a.nonce, src, a.joined), nil
+}
+
+func (a aeadEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	if c, ok := a.aead.(componentAead); ok {
+		dst, err = c.openComponents(nil, a.nonce, src, a.additional)
+	} else {
+		dst, err = a.aead.Open(nil, a.nonce, src, a.joined)
+	}
+
+	if err != nil {
+		return nil, &AuthError{Mode: a.name}
+	}
+
+	return
+}
+
+func (a aeadEncryptor) encryptAppend(dst, src []byte) ([]byte, error) {
+	if c, ok := a.aead.(componentAead); ok {
+		return c.sealComponents(dst, a.nonce, src, a.additional), nil
+	}
+
+	return a.aead.Seal(dst, a.nonce, src, a.joined), nil
+}
+
+func (a aeadEncryptor) decryptAppend(dst, src []byte) (out []byte, err error) {
+	if c, ok := a.aead.(componentAead); ok {
+		out, err = c.openComponents(dst, a.nonce, src, a.additional)
+	} else {
+		out, err = a.aead.Open(dst, a.nonce, src, a.joined)
+	}
+
+	if err != nil {
+		return nil, &AuthError{Mode: a.name}
+	}
+
+	return
+}
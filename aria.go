@@ -0,0 +1,195 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+const ariaBlockSize = 16
+
+// ariaC 1/π 小数部分的前 384 位
+var ariaC = [3][16]byte{
+	{0x51, 0x7c, 0xc1, 0xb7, 0x27, 0x22, 0x0a, 0x94, 0xfe, 0x13, 0xab, 0xe8, 0xfa, 0x9a, 0x6e, 0xe0},
+	{0x6d, 0xb1, 0x4a, 0xcc, 0x9e, 0x21, 0xc8, 0x20, 0xff, 0x28, 0xb1, 0xd5, 0xef, 0x5d, 0xe2, 0xb0},
+	{0xdb, 0x92, 0x37, 0x1d, 0x21, 0x26, 0xe9, 0x70, 0x03, 0x24, 0x97, 0x75, 0x04, 0xe8, 0xc9, 0x0e},
+}
+
+// ariaSbox SB1, SB2 与它们的逆 SB3, SB4
+var ariaSbox = func() (s [4][256]byte) {
+	for x := 0; x < 256; x++ {
+		s[0][x] = ariaSb1[x]
+		s[1][x] = ariaSb2[x]
+		s[2][ariaSb1[x]] = byte(x)
+		s[3][ariaSb2[x]] = byte(x)
+	}
+
+	return
+}()
+
+type ariaCipher struct {
+	enc [][16]byte
+	dec [][16]byte
+}
+
+// NewAriaCipher RFC 5794 的 ARIA 分组密码, key 为 16, 24 或 32 字节
+func NewAriaCipher(key []byte) (cipher.Block, error) {
+	var ck [3][16]byte
+	switch len(key) {
+	case 16:
+		ck = ariaC
+	case 24:
+		ck = [3][16]byte{ariaC[1], ariaC[2], ariaC[0]}
+	case 32:
+		ck = [3][16]byte{ariaC[2], ariaC[0], ariaC[1]}
+	default:
+		return nil, &KeyError{Algorithm: "aria", Size: len(key)}
+	}
+
+	var w0, kr [16]byte
+	copy(w0[:], key)
+	copy(kr[:], key[16:])
+
+	w1 := ariaXor(ariaFo(w0, ck[0]), kr)
+	w2 := ariaXor(ariaFe(w1, ck[1]), w0)
+	w3 := ariaXor(ariaFo(w2, ck[2]), w1)
+
+	rounds := len(key)/4 + 8
+	w := [4][16]byte{w0, w1, w2, w3}
+	c := &ariaCipher{enc: make([][16]byte, rounds+1), dec: make([][16]byte, rounds+1)}
+	// 依次右移 19, 31 位, 左移 61, 31, 19 位
+	for i, shift := range []int{19, 31, 128 - 61, 128 - 31, 128 - 19} {
+		for j := 0; j < 4 && 4*i+j <= rounds; j++ {
+			c.enc[4*i+j] = ariaXor(w[j], ariaRotate(w[(j+1)%4], shift))
+		}
+	}
+
+	c.dec[0] = c.enc[rounds]
+	for i := 1; i < rounds; i++ {
+		c.dec[i] = ariaA(c.enc[rounds-i])
+	}
+
+	c.dec[rounds] = c.enc[0]
+	return c, nil
+}
+
+func (c *ariaCipher) BlockSize() int {
+	return ariaBlockSize
+}
+
+func (c *ariaCipher) Encrypt(dst, src []byte) {
+	ariaCrypt(c.enc, dst, src)
+}
+
+func (c *ariaCipher) Decrypt(dst, src []byte) {
+	ariaCrypt(c.dec, dst, src)
+}
+
+// ariaCrypt 奇数轮使用 FO, 偶数轮使用 FE, 最后一轮没有扩散层
+func ariaCrypt(keys [][16]byte, dst, src []byte) {
+	var p [16]byte
+	copy(p[:], src[:ariaBlockSize])
+
+	rounds := len(keys) - 1
+	for i := 0; i < rounds-1; i++ {
+		if i%2 == 0 {
+			p = ariaFo(p, keys[i])
+		} else {
+			p = ariaFe(p, keys[i])
+		}
+	}
+
+	p = ariaXor(ariaSl(p, keys[rounds-1], 2), keys[rounds])
+	copy(dst, p[:])
+}
+
+func ariaFo(d, key [16]byte) [16]byte {
+	return ariaA(ariaSl(d, key, 0))
+}
+
+func ariaFe(d, key [16]byte) [16]byte {
+	return ariaA(ariaSl(d, key, 2))
+}
+
+// ariaSl 与轮密钥异或后代换, first 为第一个字节使用的 S 盒
+// (SL1 依次为 SB1, SB2, SB3, SB4, SL2 依次为 SB3, SB4, SB1, SB2)
+func ariaSl(d, key [16]byte, first int) (out [16]byte) {
+	for i := range out {
+		out[i] = ariaSbox[(first+i)%4][d[i]^key[i]]
+	}
+
+	return
+}
+
+// ariaA 16 字节的二元扩散层, 是对合变换
+func ariaA(x [16]byte) (y [16]byte) {
+	y[0] = x[3] ^ x[4] ^ x[6] ^ x[8] ^ x[9] ^ x[13] ^ x[14]
+	y[1] = x[2] ^ x[5] ^ x[7] ^ x[8] ^ x[9] ^ x[12] ^ x[15]
+	y[2] = x[1] ^ x[4] ^ x[6] ^ x[10] ^ x[11] ^ x[12] ^ x[15]
+	y[3] = x[0] ^ x[5] ^ x[7] ^ x[10] ^ x[11] ^ x[13] ^ x[14]
+	y[4] = x[0] ^ x[2] ^ x[5] ^ x[8] ^ x[11] ^ x[14] ^ x[15]
+	y[5] = x[1] ^ x[3] ^ x[4] ^ x[9] ^ x[10] ^ x[14] ^ x[15]
+	y[6] = x[0] ^ x[2] ^ x[7] ^ x[9] ^ x[10] ^ x[12] ^ x[13]
+	y[7] = x[1] ^ x[3] ^ x[6] ^ x[8] ^ x[11] ^ x[12] ^ x[13]
+	y[8] = x[0] ^ x[1] ^ x[4] ^ x[7] ^ x[10] ^ x[13] ^ x[15]
+	y[9] = x[0] ^ x[1] ^ x[5] ^ x[6] ^ x[11] ^ x[12] ^ x[14]
+	y[10] = x[2] ^ x[3] ^ x[5] ^ x[6] ^ x[8] ^ x[13] ^ x[15]
+	y[11] = x[2] ^ x[3] ^ x[4] ^ x[7] ^ x[9] ^ x[12] ^ x[14]
+	y[12] = x[1] ^ x[2] ^ x[6] ^ x[7] ^ x[9] ^ x[11] ^ x[12]
+	y[13] = x[0] ^ x[3] ^ x[6] ^ x[7] ^ x[8] ^ x[10] ^ x[13]
+	y[14] = x[0] ^ x[3] ^ x[4] ^ x[5] ^ x[9] ^ x[11] ^ x[14]
+	y[15] = x[1] ^ x[2] ^ x[4] ^ x[5] ^ x[8] ^ x[10] ^ x[15]
+	return
+}
+
+func ariaXor(a, b [16]byte) (out [16]byte) {
+	subtle.XORBytes(out[:], a[:], b[:])
+	return
+}
+
+// ariaRotate 128 位循环右移 n 位
+func ariaRotate(x [16]byte, n int) (out [16]byte) {
+	q, r := n/8, uint(n%8)
+	for i := range out {
+		out[i] = x[(i-q+16)%16]>>r | x[(i-q+15)%16]<<(8-r)
+	}
+
+	return
+}
+
+var ariaSb1 = [256]byte{
+	0x63, 0x7c, 0x77, 0x7b, 0xf2, 0x6b, 0x6f, 0xc5, 0x30, 0x01, 0x67, 0x2b, 0xfe, 0xd7, 0xab, 0x76,
+	0xca, 0x82, 0xc9, 0x7d, 0xfa, 0x59, 0x47, 0xf0, 0xad, 0xd4, 0xa2, 0xaf, 0x9c, 0xa4, 0x72, 0xc0,
+	0xb7, 0xfd, 0x93, 0x26, 0x36, 0x3f, 0xf7, 0xcc, 0x34, 0xa5, 0xe5, 0xf1, 0x71, 0xd8, 0x31, 0x15,
+	0x04, 0xc7, 0x23, 0xc3, 0x18, 0x96, 0x05, 0x9a, 0x07, 0x12, 0x80, 0xe2, 0xeb, 0x27, 0xb2, 0x75,
+	0x09, 0x83, 0x2c, 0x1a, 0x1b, 0x6e, 0x5a, 0xa0, 0x52, 0x3b, 0xd6, 0xb3, 0x29, 0xe3, 0x2f, 0x84,
+	0x53, 0xd1, 0x00, 0xed, 0x20, 0xfc, 0xb1, 0x5b, 0x6a, 0xcb, 0xbe, 0x39, 0x4a, 0x4c, 0x58, 0xcf,
+	0xd0, 0xef, 0xaa, 0xfb, 0x43, 0x4d, 0x33, 0x85, 0x45, 0xf9, 0x02, 0x7f, 0x50, 0x3c, 0x9f, 0xa8,
+	0x51, 0xa3, 0x40, 0x8f, 0x92, 0x9d, 0x38, 0xf5, 0xbc, 0xb6, 0xda, 0x21, 0x10, 0xff, 0xf3, 0xd2,
+	0xcd, 0x0c, 0x13, 0xec, 0x5f, 0x97, 0x44, 0x17, 0xc4, 0xa7, 0x7e, 0x3d, 0x64, 0x5d, 0x19, 0x73,
+	0x60, 0x81, 0x4f, 0xdc, 0x22, 0x2a, 0x90, 0x88, 0x46, 0xee, 0xb8, 0x14, 0xde, 0x5e, 0x0b, 0xdb,
+	0xe0, 0x32, 0x3a, 0x0a, 0x49, 0x06, 0x24, 0x5c, 0xc2, 0xd3, 0xac, 0x62, 0x91, 0x95, 0xe4, 0x79,
+	0xe7, 0xc8, 0x37, 0x6d, 0x8d, 0xd5, 0x4e, 0xa9, 0x6c, 0x56, 0xf4, 0xea, 0x65, 0x7a, 0xae, 0x08,
+	0xba, 0x78, 0x25, 0x2e, 0x1c, 0xa6, 0xb4, 0xc6, 0xe8, 0xdd, 0x74, 0x1f, 0x4b, 0xbd, 0x8b, 0x8a,
+	0x70, 0x3e, 0xb5, 0x66, 0x48, 0x03, 0xf6, 0x0e, 0x61, 0x35, 0x57, 0xb9, 0x86, 0xc1, 0x1d, 0x9e,
+	0xe1, 0xf8, 0x98, 0x11, 0x69, 0xd9, 0x8e, 0x94, 0x9b, 0x1e, 0x87, 0xe9, 0xce, 0x55, 0x28, 0xdf,
+	0x8c, 0xa1, 0x89, 0x0d, 0xbf, 0xe6, 0x42, 0x68, 0x41, 0x99, 0x2d, 0x0f, 0xb0, 0x54, 0xbb, 0x16,
+}
+
+var ariaSb2 = [256]byte{
+	0xe2, 0x4e, 0x54, 0xfc, 0x94, 0xc2, 0x4a, 0xcc, 0x62, 0x0d, 0x6a, 0x46, 0x3c, 0x4d, 0x8b, 0xd1,
+	0x5e, 0xfa, 0x64, 0xcb, 0xb4, 0x97, 0xbe, 0x2b, 0xbc, 0x77, 0x2e, 0x03, 0xd3, 0x19, 0x59, 0xc1,
+	0x1d, 0x06, 0x41, 0x6b, 0x55, 0xf0, 0x99, 0x69, 0xea, 0x9c, 0x18, 0xae, 0x63, 0xdf, 0xe7, 0xbb,
+	0x00, 0x73, 0x66, 0xfb, 0x96, 0x4c, 0x85, 0xe4, 0x3a, 0x09, 0x45, 0xaa, 0x0f, 0xee, 0x10, 0xeb,
+	0x2d, 0x7f, 0xf4, 0x29, 0xac, 0xcf, 0xad, 0x91, 0x8d, 0x78, 0xc8, 0x95, 0xf9, 0x2f, 0xce, 0xcd,
+	0x08, 0x7a, 0x88, 0x38, 0x5c, 0x83, 0x2a, 0x28, 0x47, 0xdb, 0xb8, 0xc7, 0x93, 0xa4, 0x12, 0x53,
+	0xff, 0x87, 0x0e, 0x31, 0x36, 0x21, 0x58, 0x48, 0x01, 0x8e, 0x37, 0x74, 0x32, 0xca, 0xe9, 0xb1,
+	0xb7, 0xab, 0x0c, 0xd7, 0xc4, 0x56, 0x42, 0x26, 0x07, 0x98, 0x60, 0xd9, 0xb6, 0xb9, 0x11, 0x40,
+	0xec, 0x20, 0x8c, 0xbd, 0xa0, 0xc9, 0x84, 0x04, 0x49, 0x23, 0xf1, 0x4f, 0x50, 0x1f, 0x13, 0xdc,
+	0xd8, 0xc0, 0x9e, 0x57, 0xe3, 0xc3, 0x7b, 0x65, 0x3b, 0x02, 0x8f, 0x3e, 0xe8, 0x25, 0x92, 0xe5,
+	0x15, 0xdd, 0xfd, 0x17, 0xa9, 0xbf, 0xd4, 0x9a, 0x7e, 0xc5, 0x39, 0x67, 0xfe, 0x76, 0x9d, 0x43,
+	0xa7, 0xe1, 0xd0, 0xf5, 0x68, 0xf2, 0x1b, 0x34, 0x70, 0x05, 0xa3, 0x8a, 0xd5, 0x79, 0x86, 0xa8,
+	0x30, 0xc6, 0x51, 0x4b, 0x1e, 0xa6, 0x27, 0xf6, 0x35, 0xd2, 0x6e, 0x24, 0x16, 0x82, 0x5f, 0xda,
+	0xe6, 0x75, 0xa2, 0xef, 0x2c, 0xb2, 0x1c, 0x9f, 0x5d, 0x6f, 0x80, 0x0a, 0x72, 0x44, 0x9b, 0x6c,
+	0x90, 0x0b, 0x5b, 0x33, 0x7d, 0x5a, 0x52, 0xf3, 0x61, 0xa1, 0xf7, 0xb0, 0xd6, 0x3f, 0x7c, 0x6d,
+	0xed, 0x14, 0xe0, 0xa5, 0x3d, 0x22, 0xb3, 0xf8, 0x89, 0xde, 0x71, 0x1a, 0xaf, 0xba, 0xb5, 0x81,
+}
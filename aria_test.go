@@ -0,0 +1,28 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAria(t *testing.T) {
+	// RFC 5794 附录 A.1
+	testBlockVectors(t, NewAriaCipher, [][3]string{
+		{"000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "d718fbd6ab644c739da95f3be6451778"},
+		{"000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff", "26449c1805dbe7aa25a468ce263a9e79"},
+		{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff", "f92bd7c79fb72e2f2b8f80c1972d24fc"},
+	})
+
+	for _, size := range []int{16, 24, 32} {
+		testBlockModes(t, NewAria, make([]byte, size), iv)
+	}
+}
+
+func TestAriaKeyLength(t *testing.T) {
+	// 只支持 16, 24, 32 字节的密钥
+	for _, size := range []int{0, 8, 15, 17, 23, 25, 31, 33, 48, 64} {
+		_, err := TryNewAria(make([]byte, size), iv)
+		assert.Equal(t, &KeyError{Algorithm: "aria", Size: size}, err)
+	}
+}
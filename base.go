@@ -34,23 +34,36 @@ const (
 )
 
 var (
-	ErrPaddingSize = errors.New("padding size invalid")
-	ErrKeyLength   = errors.New("key length invalid")
+	ErrPaddingSize   = errors.New("padding size invalid")
+	ErrKeyLength     = errors.New("key length invalid")
+	ErrIvLength      = errors.New("iv length invalid")
+	ErrNoKey         = errors.New("method was created without key")
+	ErrAuthFailed    = errors.New("message authentication failed")
+	ErrIvPrefix      = errors.New("cipher text shorter than iv prefix")
+	ErrNotFullBlocks = errors.New("input not full blocks")
 
 	base64Wrap     = &Base64Wrap{}
 	base64SafeWrap = &Base64SafeWrap{}
 	hexWrap        = &HexWrap{}
 
-	noPadding    = &NoPadding{}
-	pkcs7Padding = &Pkcs7Padding{}
-	zeroPadding  = &ZeroPadding{}
+	noPadding       = &NoPadding{}
+	legacyNoPadding = &LegacyNoPadding{}
+	pkcs7Padding    = &Pkcs7Padding{}
+	zeroPadding     = &ZeroPadding{}
+	x923Padding     = &AnsiX923Padding{}
+	iso10126Padding = &Iso10126Padding{}
+	iso7816Padding  = &Iso7816Padding{}
 )
 
 type IPaddingType interface {
 	NoPadding() IEncrypt
+	LegacyNoPadding() IEncrypt
 	ZeroPadding() IEncrypt
 	Pkcs5Padding() IEncrypt
 	Pkcs7Padding() IEncrypt
+	AnsiX923Padding() IEncrypt
+	Iso10126Padding() IEncrypt
+	Iso7816Padding() IEncrypt
 }
 
 type IWrapType interface {
@@ -63,12 +76,14 @@ type IEncrypt interface {
 	IPaddingType
 	IWrapType
 	IEncryptor
+	EncryptAppend(dst, src []byte) ([]byte, error)
+	DecryptAppend(dst, src []byte) ([]byte, error)
 	//Encrypt([]byte) []byte
 	//Decrypt([]byte) ([]byte, error)
 }
 
 type IEncryptor interface {
-	Encrypt(src []byte) (dst []byte)
+	Encrypt(src []byte) (dst []byte, err error)
 	Decrypt(src []byte) (dst []byte, err error)
 }
 
@@ -81,25 +96,70 @@ type Base struct {
 	encryptor IEncryptor
 }
 
-func (a *Base) Encrypt(text []byte) []byte {
-	plainText := a.fill(text)
-	return a.encode(
-		a.encryptor.Encrypt(plainText),
-	)
+func (a *Base) Encrypt(text []byte) (dst []byte, err error) {
+	return a.EncryptAppend(nil, text)
 }
 
 func (a *Base) Decrypt(bytes []byte) (dst []byte, err error) {
-	var src []byte
-	if src, err = a.decode(bytes); err != nil {
-		return
+	return a.DecryptAppend(nil, bytes)
+}
+
+// EncryptAppend 加密并把编码后的结果追加到 dst, dst 容量足够时不再分配内存
+func (a *Base) EncryptAppend(dst, src []byte) ([]byte, error) {
+	if a.padding != nil {
+		plain := getBuffer()
+		defer putBuffer(plain)
+
+		*plain = a.padding.Fill(append((*plain)[:0], src...), a.blockSize())
+		src = *plain
+	}
+
+	if a.wrap == nil {
+		return encryptAppend(a.encryptor, dst, src)
+	}
+
+	crypto := getBuffer()
+	defer putBuffer(crypto)
+
+	var err error
+	if *crypto, err = encryptAppend(a.encryptor, (*crypto)[:0], src); err != nil {
+		return nil, err
+	}
+
+	return a.wrap.EncodeAppend(dst, *crypto), nil
+}
+
+// DecryptAppend 解码, 解密并去掉填充后追加到 dst
+func (a *Base) DecryptAppend(dst, src []byte) ([]byte, error) {
+	if a.wrap != nil {
+		decoded := getBuffer()
+		defer putBuffer(decoded)
+
+		var err error
+		if *decoded, err = a.wrap.DecodeAppend((*decoded)[:0], src); err != nil {
+			return nil, err
+		}
+
+		src = *decoded
 	}
 
-	var decrypted []byte
-	if decrypted, err = a.encryptor.Decrypt(src); err != nil {
-		return
+	out, err := decryptAppend(a.encryptor, dst, src)
+	if err != nil {
+		return nil, err
 	}
 
-	return a.restore(decrypted), nil
+	text, err := a.restore(out[len(dst):])
+	if err != nil {
+		// 填充错误时明文已经写入 dst 的剩余容量, 清除后再返回
+		plain := out[len(dst):]
+		for i := range plain {
+			plain[i] = 0
+		}
+
+		return nil, err
+	}
+
+	return append(dst, text...), nil
 }
 
 //func NewMethod(block cipher.Block, iv []byte) *Base {
@@ -109,69 +169,81 @@ func (a *Base) Decrypt(bytes []byte) (dst []byte, err error) {
 //}
 
 func (a *Base) NoPadding() IEncrypt {
-	a.padding = noPadding
-	return a
+	return a.withPadding(noPadding)
+}
+
+// LegacyNoPadding 旧版 NoPadding 的行为: 补零到分组长度, 还原时去掉末尾所有的零
+func (a *Base) LegacyNoPadding() IEncrypt {
+	return a.withPadding(legacyNoPadding)
 }
 
 func (a *Base) ZeroPadding() IEncrypt {
-	a.padding = zeroPadding
-	return a
+	return a.withPadding(zeroPadding)
 }
 
 func (a *Base) Pkcs5Padding() IEncrypt {
 	// pkcs7 向下兼容pkcs5Padding
-	a.padding = pkcs7Padding
-	return a
+	return a.withPadding(pkcs7Padding)
 }
 
 func (a *Base) Pkcs7Padding() IEncrypt {
-	a.padding = pkcs7Padding
-	return a
+	return a.withPadding(pkcs7Padding)
+}
+
+func (a *Base) AnsiX923Padding() IEncrypt {
+	return a.withPadding(x923Padding)
+}
+
+func (a *Base) Iso10126Padding() IEncrypt {
+	return a.withPadding(iso10126Padding)
+}
+
+func (a *Base) Iso7816Padding() IEncrypt {
+	return a.withPadding(iso7816Padding)
 }
 
 func (a *Base) Base64Safe() IEncrypt {
-	a.wrap = base64SafeWrap
-	return a
+	return a.withWrap(base64SafeWrap)
 }
 
 func (a *Base) Base64() IEncrypt {
-	a.wrap = base64Wrap
-	return a
+	return a.withWrap(base64Wrap)
 }
 
 func (a *Base) Hex() IEncrypt {
-	a.wrap = hexWrap
-	return a
+	return a.withWrap(hexWrap)
 }
 
-func (a *Base) fill(text []byte) []byte {
-	if a.padding == nil {
-		return text
-	}
-
-	return a.padding.Fill(text, a.block.BlockSize())
+// withPadding 返回设置了填充的副本, 原来的值保持不变, 可以在多个协程中共享
+func (a *Base) withPadding(padding IPadding) IEncrypt {
+	b := *a
+	b.padding = padding
+	return &b
 }
 
-func (a *Base) restore(text []byte) []byte {
-	if a.padding == nil {
-		return text
-	}
+func (a *Base) withWrap(wrap IWrap) IEncrypt {
+	b := *a
+	b.wrap = wrap
+	return &b
+}
 
-	return a.padding.Restore(text, a.block.BlockSize())
+func (a *Base) base() *Base {
+	return a
 }
 
-func (a *Base) encode(crypto []byte) []byte {
-	if a.wrap == nil {
-		return crypto
+func (a *Base) restore(text []byte) ([]byte, error) {
+	if a.padding == nil {
+		return text, nil
 	}
 
-	return a.wrap.Encode(crypto)
+	return a.padding.Restore(text, a.blockSize())
 }
 
-func (a *Base) decode(crypto []byte) ([]byte, error) {
-	if a.wrap == nil {
-		return crypto, nil
+// blockSize 流密码没有分组, 按 1 字节处理
+func (a *Base) blockSize() int {
+	if a.block == nil {
+		return 1
 	}
 
-	return a.wrap.Decode(crypto)
+	return a.block.BlockSize()
 }
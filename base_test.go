@@ -0,0 +1,201 @@
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderImmutable(t *testing.T) {
+	text := []byte("xq1_ddq")
+	cbc := NewAes(key, iv).CBC()
+
+	pkcs7 := cbc.Pkcs7Padding()
+	expected := mustEncrypt(t, pkcs7, text)
+
+	// 派生新的配置不影响已有的值
+	cbc.ZeroPadding().Hex()
+	pkcs7.Base64()
+	assert.Equal(t, expected, mustEncrypt(t, pkcs7, text))
+
+	_, err := cbc.Encrypt(text)
+	assert.ErrorIs(t, err, ErrNotFullBlocks)
+
+	gcm := NewAes(key, iv).GCM()
+	withHeader := gcm.Additional([]byte("header"))
+	encrypted := mustEncrypt(t, gcm, text)
+	_, err = withHeader.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	decrypted, err := gcm.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+}
+
+// 使用 go test -race 运行
+func TestBuilderConcurrent(t *testing.T) {
+	method := NewAes(key, iv)
+	builds := []func() IEncrypt{
+		func() IEncrypt { return method.CBC().Pkcs7Padding().Base64() },
+		func() IEncrypt { return method.CBC().Iso7816Padding().Hex() },
+		func() IEncrypt { return method.ECB().ZeroPadding().Base64Safe() },
+		func() IEncrypt { return method.CTR().Hex() },
+		func() IEncrypt { return method.CFB8().Base64() },
+		func() IEncrypt { return method.RandomIv().OFB().Base64() },
+		func() IEncrypt { return method.GCM().FixedNonce().Additional([]byte("header")).Base64() },
+		func() IEncrypt { return method.RandomIv().OCB().TagSize(12).Hex() },
+		func() IEncrypt { return method.EAX().FixedNonce().Hex() },
+		func() IEncrypt { return method.CCM().NonceSize(7).FixedNonce().Base64() },
+	}
+
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	expected := make([][]byte, len(builds))
+	for i, build := range builds {
+		expected[i] = mustEncrypt(t, build(), text)
+	}
+
+	shared := builds[0]()
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		for i, build := range builds {
+			wg.Add(1)
+			go func(i int, build func() IEncrypt) {
+				defer wg.Done()
+				encryptor := build()
+				for j := 0; j < 20; j++ {
+					plain := []byte(fmt.Sprintf("%s-%d", text, j))
+					encrypted, err := encryptor.Encrypt(plain)
+					assert.NoError(t, err)
+
+					decrypted, err := encryptor.Decrypt(encrypted)
+					assert.NoError(t, err)
+					assert.Equal(t, plain, decrypted)
+
+					// 同一个值在多个协程中使用
+					decrypted, err = shared.Decrypt(expected[0])
+					assert.NoError(t, err)
+					assert.Equal(t, text, decrypted)
+				}
+
+				// 固定 iv 的模式结果与单协程一致
+				if i != 5 && i != 7 {
+					assert.Equal(t, expected[i], mustEncrypt(t, encryptor, text))
+				}
+			}(i, build)
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestEncryptAppend(t *testing.T) {
+	encryptors := map[string]IEncrypt{
+		"cbc":      NewAes(key, iv).CBC().Pkcs7Padding().Base64(),
+		"cbc-raw":  NewAes(key, iv).CBC().Iso7816Padding(),
+		"ecb":      NewAes(key, nil).ECB().Pkcs7Padding().Hex(),
+		"ctr":      NewAes(key, iv).CTR().Base64Safe(),
+		"random":   NewAes(key, nil).RandomIv().CBC().Pkcs7Padding().Base64(),
+		"gcm":      NewAes(key, iv).GCM().Additional([]byte("header")).Base64(),
+		"siv":      NewAes(append(bytes.Clone(key), key...), nil).SIV().Additional([]byte("a"), []byte("b")),
+		"chacha20": NewChaCha20Poly1305(make([]byte, 32), make([]byte, 12)).Hex(),
+	}
+
+	prefix := []byte("prefix:")
+	for name, encryptor := range encryptors {
+		t.Run(name, func(t *testing.T) {
+			text := generate()
+			encrypted, err := encryptor.EncryptAppend(bytes.Clone(prefix), text)
+			assert.NoError(t, err)
+			assert.Equal(t, prefix, encrypted[:len(prefix)])
+
+			decrypted, err := encryptor.Decrypt(encrypted[len(prefix):])
+			assert.NoError(t, err)
+			assert.Equal(t, text, decrypted)
+
+			decrypted, err = encryptor.DecryptAppend(bytes.Clone(prefix), encrypted[len(prefix):])
+			assert.NoError(t, err)
+			assert.Equal(t, append(bytes.Clone(prefix), text...), decrypted)
+		})
+	}
+}
+
+func TestEncryptAppendInPlace(t *testing.T) {
+	text := []byte("1234567890abcdefghijklmnopqrstuvwxyz")
+	encryptors := []IEncrypt{
+		NewAes(key, iv).CBC().NoPadding(),
+		NewAes(key, nil).ECB().NoPadding(),
+		NewAes(key, iv).GCM().FixedNonce(),
+	}
+
+	for _, encryptor := range encryptors {
+		expected := mustEncrypt(t, encryptor, text[:32])
+
+		buf := make([]byte, 32, 64)
+		copy(buf, text)
+		encrypted, err := encryptor.EncryptAppend(buf[:0], buf)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, encrypted)
+
+		decrypted, err := encryptor.DecryptAppend(encrypted[:0], encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, text[:32], decrypted)
+	}
+}
+
+func TestDecryptAppendPaddingError(t *testing.T) {
+	encrypted := mustEncrypt(t, NewAes(key, iv).CBC().NoPadding(), bytes.Repeat([]byte{0xff}, 32))
+
+	buf := make([]byte, 0, 64)
+	_, err := NewAes(key, iv).CBC().Pkcs7Padding().DecryptAppend(buf, encrypted)
+	assert.ErrorIs(t, err, ErrPaddingSize)
+	assert.Equal(t, make([]byte, 64), buf[:64])
+}
+
+func TestEncryptAppendAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector")
+	}
+
+	encryptor := NewAes(key, iv).CBC().Pkcs7Padding().Base64()
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	encrypted := make([]byte, 0, 128)
+	decrypted := make([]byte, 0, 128)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		if encrypted, err = encryptor.EncryptAppend(encrypted[:0], text); err != nil {
+			t.Fatal(err)
+		}
+
+		if decrypted, err = encryptor.DecryptAppend(decrypted[:0], encrypted); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	assert.Equal(t, text, decrypted)
+	assert.LessOrEqual(t, allocs, float64(0))
+}
+
+func BenchmarkEncryptAppend(b *testing.B) {
+	encryptor := NewAes(key, iv).CBC().Pkcs7Padding().Base64()
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	encrypted := make([]byte, 0, 128)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encrypted, _ = encryptor.EncryptAppend(encrypted[:0], text)
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	encryptor := NewAes(key, iv).CBC().Pkcs7Padding().Base64()
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = encryptor.Encrypt(text)
+	}
+}
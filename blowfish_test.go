@@ -0,0 +1,53 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlowfish(t *testing.T) {
+	// Eric Young 的 Blowfish 测试向量
+	testBlockVectors(t, NewBlowfishCipher, [][3]string{
+		{"0000000000000000", "0000000000000000", "4ef997456198dd78"},
+		{"ffffffffffffffff", "ffffffffffffffff", "51866fd5b85ecb8a"},
+		{"3000000000000000", "1000000000000001", "7d856f9a613063f2"},
+		{"fedcba9876543210", "0123456789abcdef", "0aceab0fc6a0a28d"},
+	})
+
+	testBlockModes(t, NewBlowfish, key, iv[:8])
+}
+
+func TestBlowfishKeyLength(t *testing.T) {
+	// Eric Young 的 set_key 向量, 同一个密钥取前 1-24 字节
+	long, _ := hex.DecodeString("f0e1d2c3b4a5968778695a4b3c2d1e0f0011223344556677")
+	expected := []string{
+		"f9ad597c49db005e", "e91d21c1d961a6d6", "e9c2b70a1bc65cf3", "be1e639408640f05",
+		"b39e44481bdb1e6e", "9457aa83b1928c0d", "8bb77032f960629d", "e87a244e2cc85e82",
+		"15750e7a4f4ec577", "122ba70b3ab64ae0", "3a833c9affc537f6", "9409da87a90f6bf2",
+		"884f80625060b8b4", "1f85031c19e11968", "79d9373a714ca34f", "93142887ee3be15c",
+		"03429e838ce2d14b", "a4299e27469ff67b", "afd5aed1c1bc96a8", "10851c0e3858da9f",
+		"e6f51ed79b9db21f", "64a6e14afd36b46f", "80c7d7d45a5479ad", "05044b62fa52d080",
+	}
+
+	vectors := make([][3]string, len(expected))
+	for i, cipherText := range expected {
+		vectors[i] = [3]string{hex.EncodeToString(long[:i+1]), "fedcba9876543210", cipherText}
+	}
+
+	// 最长 56 字节, 结果与 golang.org/x/crypto/blowfish 一致
+	max := make([]byte, 56)
+	for i := range max {
+		max[i] = byte(i)
+	}
+
+	vectors = append(vectors, [3]string{hex.EncodeToString(max), "0000000000000000", "5df23f8894102401"})
+	testBlockVectors(t, NewBlowfishCipher, vectors)
+
+	for _, size := range []int{0, 57, 64} {
+		_, err := TryNewBlowfish(make([]byte, size), iv[:8])
+		assert.Equal(t, &KeyError{Algorithm: "blowfish", Size: size}, err)
+		assert.ErrorIs(t, err, ErrKeyLength)
+	}
+}
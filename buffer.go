@@ -0,0 +1,32 @@
+package encrypt
+
+import "sync"
+
+// maxPooledBuffer 超过这个容量的缓冲区不放回池中, 避免长期占用大块内存
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer 放回前清空内容, 缓冲区中可能有明文
+func putBuffer(buf *[]byte) {
+	b := *buf
+	for i := range b {
+		b[i] = 0
+	}
+
+	if cap(b) > maxPooledBuffer {
+		return
+	}
+
+	*buf = b[:0]
+	bufferPool.Put(buf)
+}
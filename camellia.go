@@ -0,0 +1,220 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/bits"
+)
+
+const camelliaBlockSize = 16
+
+var camelliaSbox1 = [256]byte{
+	112, 130, 44, 236, 179, 39, 192, 229, 228, 133, 87, 53, 234, 12, 174, 65,
+	35, 239, 107, 147, 69, 25, 165, 33, 237, 14, 79, 78, 29, 101, 146, 189,
+	134, 184, 175, 143, 124, 235, 31, 206, 62, 48, 220, 95, 94, 197, 11, 26,
+	166, 225, 57, 202, 213, 71, 93, 61, 217, 1, 90, 214, 81, 86, 108, 77,
+	139, 13, 154, 102, 251, 204, 176, 45, 116, 18, 43, 32, 240, 177, 132, 153,
+	223, 76, 203, 194, 52, 126, 118, 5, 109, 183, 169, 49, 209, 23, 4, 215,
+	20, 88, 58, 97, 222, 27, 17, 28, 50, 15, 156, 22, 83, 24, 242, 34,
+	254, 68, 207, 178, 195, 181, 122, 145, 36, 8, 232, 168, 96, 252, 105, 80,
+	170, 208, 160, 125, 161, 137, 98, 151, 84, 91, 30, 149, 224, 255, 100, 210,
+	16, 196, 0, 72, 163, 247, 117, 219, 138, 3, 230, 218, 9, 63, 221, 148,
+	135, 92, 131, 2, 205, 74, 144, 51, 115, 103, 246, 243, 157, 127, 191, 226,
+	82, 155, 216, 38, 200, 55, 198, 59, 129, 150, 111, 75, 19, 190, 99, 46,
+	233, 121, 167, 140, 159, 110, 188, 142, 41, 245, 249, 182, 47, 253, 180, 89,
+	120, 152, 6, 106, 231, 70, 113, 186, 212, 37, 171, 66, 136, 162, 141, 250,
+	114, 7, 185, 85, 248, 238, 172, 10, 54, 73, 42, 104, 60, 56, 241, 164,
+	64, 40, 211, 123, 187, 201, 67, 193, 21, 227, 173, 244, 119, 199, 128, 158,
+}
+
+// camelliaSbox 四个 S 盒都由 SBOX1 旋转得到
+var camelliaSbox = func() (s [4][256]byte) {
+	for x := 0; x < 256; x++ {
+		v := camelliaSbox1[x]
+		s[0][x] = v
+		s[1][x] = bits.RotateLeft8(v, 1)
+		s[2][x] = bits.RotateLeft8(v, 7)
+		s[3][x] = camelliaSbox1[bits.RotateLeft8(byte(x), 1)]
+	}
+
+	return
+}()
+
+var camelliaSigma = [6]uint64{
+	0xa09e667f3bcc908b, 0xb67ae8584caa73b2, 0xc6ef372fe94f82be,
+	0x54ff53a5f1d36f1c, 0x10e527fade682d1d, 0xb05688c2b3e6c1fd,
+}
+
+// camelliaKey 128 位整数, 用于子密钥的循环移位
+type camelliaKey struct {
+	hi, lo uint64
+}
+
+func (k camelliaKey) rotate(n uint) camelliaKey {
+	for ; n >= 64; n -= 64 {
+		k.hi, k.lo = k.lo, k.hi
+	}
+
+	if n == 0 {
+		return k
+	}
+
+	return camelliaKey{k.hi<<n | k.lo>>(64-n), k.lo<<n | k.hi>>(64-n)}
+}
+
+type camelliaCipher struct {
+	kw [4]uint64
+	k  []uint64
+	ke []uint64
+}
+
+// NewCamelliaCipher RFC 3713 的 Camellia 分组密码, key 为 16, 24 或 32 字节
+func NewCamelliaCipher(key []byte) (cipher.Block, error) {
+	var kl, kr camelliaKey
+	switch len(key) {
+	case 16:
+		kl = camelliaKey{binary.BigEndian.Uint64(key), binary.BigEndian.Uint64(key[8:])}
+	case 24:
+		kl = camelliaKey{binary.BigEndian.Uint64(key), binary.BigEndian.Uint64(key[8:])}
+		right := binary.BigEndian.Uint64(key[16:])
+		kr = camelliaKey{right, ^right}
+	case 32:
+		kl = camelliaKey{binary.BigEndian.Uint64(key), binary.BigEndian.Uint64(key[8:])}
+		kr = camelliaKey{binary.BigEndian.Uint64(key[16:]), binary.BigEndian.Uint64(key[24:])}
+	default:
+		return nil, &KeyError{Algorithm: "camellia", Size: len(key)}
+	}
+
+	d1, d2 := kl.hi^kr.hi, kl.lo^kr.lo
+	d2 ^= camelliaF(d1, camelliaSigma[0])
+	d1 ^= camelliaF(d2, camelliaSigma[1])
+	d1 ^= kl.hi
+	d2 ^= kl.lo
+	d2 ^= camelliaF(d1, camelliaSigma[2])
+	d1 ^= camelliaF(d2, camelliaSigma[3])
+	ka := camelliaKey{d1, d2}
+
+	// 每一项为 (密钥, 左移位数), 依次展开为 kw, k, ke 的高低 64 位
+	type part struct {
+		key   camelliaKey
+		shift uint
+	}
+
+	var kw, k, ke []part
+	if len(key) == 16 {
+		kw = []part{{kl, 0}, {ka, 111}}
+		k = []part{{ka, 0}, {kl, 15}, {ka, 15}, {kl, 45}, {ka, 45}, {ka, 60}, {kl, 94}, {ka, 94}, {kl, 111}}
+		ke = []part{{ka, 30}, {kl, 77}}
+	} else {
+		d1, d2 = ka.hi^kr.hi, ka.lo^kr.lo
+		d2 ^= camelliaF(d1, camelliaSigma[4])
+		d1 ^= camelliaF(d2, camelliaSigma[5])
+		kb := camelliaKey{d1, d2}
+
+		kw = []part{{kl, 0}, {kb, 111}}
+		k = []part{{kb, 0}, {kr, 15}, {ka, 15}, {kb, 30}, {kl, 45}, {ka, 45}, {kr, 60}, {kb, 60}, {kl, 77}, {kr, 94}, {ka, 94}, {kl, 111}}
+		ke = []part{{kr, 30}, {kl, 60}, {ka, 77}}
+	}
+
+	expand := func(parts []part) (out []uint64) {
+		for _, p := range parts {
+			r := p.key.rotate(p.shift)
+			out = append(out, r.hi, r.lo)
+		}
+
+		return
+	}
+
+	c := new(camelliaCipher)
+	copy(c.kw[:], expand(kw))
+	c.k = expand(k)
+	c.ke = expand(ke)
+	if len(key) == 16 {
+		// 128 位密钥的 k10 不是 KA<<<45 的低位, 而是 KL<<<60 的低位
+		c.k[9] = kl.rotate(60).lo
+	}
+
+	return c, nil
+}
+
+func camelliaF(in, key uint64) uint64 {
+	x := in ^ key
+	t1 := camelliaSbox[0][byte(x>>56)]
+	t2 := camelliaSbox[1][byte(x>>48)]
+	t3 := camelliaSbox[2][byte(x>>40)]
+	t4 := camelliaSbox[3][byte(x>>32)]
+	t5 := camelliaSbox[1][byte(x>>24)]
+	t6 := camelliaSbox[2][byte(x>>16)]
+	t7 := camelliaSbox[3][byte(x>>8)]
+	t8 := camelliaSbox[0][byte(x)]
+
+	y1 := t1 ^ t3 ^ t4 ^ t6 ^ t7 ^ t8
+	y2 := t1 ^ t2 ^ t4 ^ t5 ^ t7 ^ t8
+	y3 := t1 ^ t2 ^ t3 ^ t5 ^ t6 ^ t8
+	y4 := t2 ^ t3 ^ t4 ^ t5 ^ t6 ^ t7
+	y5 := t1 ^ t2 ^ t6 ^ t7 ^ t8
+	y6 := t2 ^ t3 ^ t5 ^ t7 ^ t8
+	y7 := t3 ^ t4 ^ t5 ^ t6 ^ t8
+	y8 := t1 ^ t4 ^ t5 ^ t6 ^ t7
+	return uint64(y1)<<56 | uint64(y2)<<48 | uint64(y3)<<40 | uint64(y4)<<32 |
+		uint64(y5)<<24 | uint64(y6)<<16 | uint64(y7)<<8 | uint64(y8)
+}
+
+func camelliaFL(x, key uint64) uint64 {
+	x1, x2 := uint32(x>>32), uint32(x)
+	k1, k2 := uint32(key>>32), uint32(key)
+	x2 ^= bits.RotateLeft32(x1&k1, 1)
+	x1 ^= x2 | k2
+	return uint64(x1)<<32 | uint64(x2)
+}
+
+func camelliaFLInv(y, key uint64) uint64 {
+	y1, y2 := uint32(y>>32), uint32(y)
+	k1, k2 := uint32(key>>32), uint32(key)
+	y1 ^= y2 | k2
+	y2 ^= bits.RotateLeft32(y1&k1, 1)
+	return uint64(y1)<<32 | uint64(y2)
+}
+
+func (c *camelliaCipher) BlockSize() int {
+	return camelliaBlockSize
+}
+
+func (c *camelliaCipher) Encrypt(dst, src []byte) {
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[0]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[1]
+	for i := 0; i < len(c.k); i += 2 {
+		// 每 6 轮之间插入 FL 层
+		if i > 0 && i%6 == 0 {
+			layer := i/6 - 1
+			d1 = camelliaFL(d1, c.ke[2*layer])
+			d2 = camelliaFLInv(d2, c.ke[2*layer+1])
+		}
+
+		d2 ^= camelliaF(d1, c.k[i])
+		d1 ^= camelliaF(d2, c.k[i+1])
+	}
+
+	binary.BigEndian.PutUint64(dst, d2^c.kw[2])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[3])
+}
+
+// Decrypt 与加密结构相同, 子密钥逆序使用
+func (c *camelliaCipher) Decrypt(dst, src []byte) {
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[2]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[3]
+	n := len(c.k)
+	for i := 0; i < n; i += 2 {
+		if i > 0 && i%6 == 0 {
+			layer := len(c.ke)/2 - i/6
+			d1 = camelliaFL(d1, c.ke[2*layer+1])
+			d2 = camelliaFLInv(d2, c.ke[2*layer])
+		}
+
+		d2 ^= camelliaF(d1, c.k[n-1-i])
+		d1 ^= camelliaF(d2, c.k[n-2-i])
+	}
+
+	binary.BigEndian.PutUint64(dst, d2^c.kw[0])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[1])
+}
@@ -0,0 +1,47 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCamellia(t *testing.T) {
+	// RFC 3713 附录 A
+	testBlockVectors(t, NewCamelliaCipher, [][3]string{
+		{"0123456789abcdeffedcba9876543210", "0123456789abcdeffedcba9876543210", "67673138549669730857065648eabe43"},
+		{"0123456789abcdeffedcba98765432100011223344556677", "0123456789abcdeffedcba9876543210", "b4993401b3e996f84ee5cee7d79b09b9"},
+		{"0123456789abcdeffedcba987654321000112233445566778899aabbccddeeff", "0123456789abcdeffedcba9876543210", "9acc237dff16d76c20ef7c919e3a7509"},
+	})
+
+	testBlockModes(t, NewCamellia, key, iv)
+}
+
+func TestCamellia192(t *testing.T) {
+	// RFC 3713: 192 位密钥的右半部分为 K[128..191] || ^K[128..191]
+	key192, _ := hex.DecodeString("0123456789abcdeffedcba98765432100011223344556677")
+	key256 := append(append([]byte(nil), key192...), make([]byte, 8)...)
+	for i := 0; i < 8; i++ {
+		key256[24+i] = ^key192[16+i]
+	}
+
+	short, err := NewCamelliaCipher(key192)
+	assert.NoError(t, err)
+	long, err := NewCamelliaCipher(key256)
+	assert.NoError(t, err)
+
+	plain := []byte("camellia 192 key")
+	first, second := make([]byte, 16), make([]byte, 16)
+	short.Encrypt(first, plain)
+	long.Encrypt(second, plain)
+	assert.Equal(t, first, second)
+}
+
+func TestCamelliaKeyLength(t *testing.T) {
+	// 只支持 16, 24, 32 字节的密钥
+	for _, size := range []int{0, 8, 15, 17, 23, 25, 31, 33, 48, 64} {
+		_, err := TryNewCamellia(make([]byte, size), iv)
+		assert.Equal(t, &KeyError{Algorithm: "camellia", Size: size}, err)
+	}
+}
@@ -0,0 +1,178 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	ccmBlockSize        = 16
+	ccmDefaultNonceSize = 12
+	ccmDefaultTagSize   = 16
+)
+
+// ccm NIST SP 800-38C / RFC 3610, CBC-MAC 认证 + CTR 加密
+type ccm struct {
+	block     cipher.Block
+	nonceSize int
+	tagSize   int
+}
+
+func newCcm(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != ccmBlockSize {
+		return nil, errors.New("ccm: block size must be 16")
+	}
+
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, errors.New("ccm: nonce size must be between 7 and 13")
+	}
+
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, errors.New("ccm: tag size must be an even number between 4 and 16")
+	}
+
+	return &ccm{block: block, nonceSize: nonceSize, tagSize: tagSize}, nil
+}
+
+func (c *ccm) NonceSize() int {
+	return c.nonceSize
+}
+
+func (c *ccm) Overhead() int {
+	return c.tagSize
+}
+
+// maxLength 长度字段占 15-nonceSize 个字节
+func (c *ccm) maxLength() uint64 {
+	l := 15 - c.nonceSize
+	if l >= 8 {
+		return 1<<63 - 1
+	}
+
+	return 1<<(8*uint(l)) - 1
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("ccm: incorrect nonce length given to CCM")
+	}
+
+	if uint64(len(plaintext)) > c.maxLength() {
+		panic("ccm: message too large for nonce size")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+c.tagSize)
+	tag := c.mac(nonce, plaintext, additionalData)
+	c.ctr(nonce, out, plaintext, tag)
+	copy(out[len(plaintext):], tag[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		panic("ccm: incorrect nonce length given to CCM")
+	}
+
+	if len(ciphertext) < c.tagSize || uint64(len(ciphertext)-c.tagSize) > c.maxLength() {
+		return nil, ErrAuthFailed
+	}
+
+	size := len(ciphertext) - c.tagSize
+	ret, out := sliceForAppend(dst, size)
+
+	var tag [ccmBlockSize]byte
+	copy(tag[:], ciphertext[size:])
+	c.ctr(nonce, out, ciphertext[:size], tag[:])
+
+	expected := c.mac(nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:c.tagSize], tag[:c.tagSize]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	return ret, nil
+}
+
+// ctr 用 A_0 加密 tag, 从 A_1 开始加密消息
+func (c *ccm) ctr(nonce, dst, src, tag []byte) {
+	var counter, s0 [ccmBlockSize]byte
+	counter[0] = byte(14 - c.nonceSize)
+	copy(counter[1:], nonce)
+
+	c.block.Encrypt(s0[:], counter[:])
+	subtle.XORBytes(tag, tag, s0[:])
+
+	counter[ccmBlockSize-1] = 1
+	cipher.NewCTR(c.block, counter[:]).XORKeyStream(dst, src)
+}
+
+func (c *ccm) mac(nonce, plaintext, additionalData []byte) []byte {
+	var b0 [ccmBlockSize]byte
+	b0[0] = byte((c.tagSize-2)/2<<3 | (14 - c.nonceSize))
+	if len(additionalData) > 0 {
+		b0[0] |= 0x40
+	}
+
+	copy(b0[1:], nonce)
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(plaintext)))
+	copy(b0[1+c.nonceSize:], length[8-(15-c.nonceSize):])
+
+	mac := newCbcMac(c.block)
+	mac.Write(b0[:])
+
+	if size := len(additionalData); size > 0 {
+		var header []byte
+		switch {
+		case size < 0xff00:
+			header = binary.BigEndian.AppendUint16(nil, uint16(size))
+		case uint64(size) < 1<<32:
+			header = binary.BigEndian.AppendUint32([]byte{0xff, 0xfe}, uint32(size))
+		default:
+			header = binary.BigEndian.AppendUint64([]byte{0xff, 0xff}, uint64(size))
+		}
+
+		mac.Write(header)
+		mac.Write(additionalData)
+		mac.pad()
+	}
+
+	mac.Write(plaintext)
+	mac.pad()
+	return mac.x[:]
+}
+
+// cbcMac 零填充的 CBC-MAC
+type cbcMac struct {
+	block cipher.Block
+	x     [ccmBlockSize]byte
+	n     int
+}
+
+func newCbcMac(block cipher.Block) *cbcMac {
+	return &cbcMac{block: block}
+}
+
+func (m *cbcMac) Write(data []byte) {
+	for len(data) > 0 {
+		c := subtle.XORBytes(m.x[m.n:], m.x[m.n:], data)
+		m.n += c
+		data = data[c:]
+		if m.n == ccmBlockSize {
+			m.block.Encrypt(m.x[:], m.x[:])
+			m.n = 0
+		}
+	}
+}
+
+func (m *cbcMac) pad() {
+	if m.n > 0 {
+		m.block.Encrypt(m.x[:], m.x[:])
+		m.n = 0
+	}
+}
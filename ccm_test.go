@@ -0,0 +1,55 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCcm(t *testing.T) {
+	ccm1 := NewAes(key, iv).CCM().Additional([]byte("header")).Base64()
+	testMethod(t, ccm1, false, nil)
+
+	ccm2 := NewAes(key, iv).CCM().NonceSize(7).TagSize(8).Hex()
+	testMethod(t, ccm2, false, nil)
+}
+
+func TestCcmRfc3610(t *testing.T) {
+	ccmKey, _ := hex.DecodeString("c0c1c2c3c4c5c6c7c8c9cacbcccdcecf")
+	additional, _ := hex.DecodeString("0001020304050607")
+	plainText, _ := hex.DecodeString("08090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+
+	cases := []struct {
+		nonce   string
+		size    int
+		tagSize int
+		result  string
+	}{
+		{"00000003020100a0a1a2a3a4a5", 23, 8, "588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0"},
+		{"00000004030201a0a1a2a3a4a5", 24, 8, "72c91a36e135f8cf291ca894085c87e3cc15c439c9e43a3ba091d56e10400916"},
+		{"00000005040302a0a1a2a3a4a5", 25, 8, "51b1e5f44a197d1da46b0f8e2d282ae871e838bb64da8596574adaa76fbd9fb0c5"},
+		{"00000009080706a0a1a2a3a4a5", 23, 10, "0135d1b2c95f41d5d1d4fec185d166b8094e999dfed96c048c56602c97acbb7490"},
+	}
+
+	for _, c := range cases {
+		nonce, _ := hex.DecodeString(c.nonce)
+		ccm1 := NewAes(ccmKey, nonce).CCM().NonceSize(len(nonce)).TagSize(c.tagSize).FixedNonce().Additional(additional).Hex()
+		encrypted := mustEncrypt(t, ccm1, plainText[:c.size])
+		assert.Equal(t, c.result, string(encrypted))
+
+		decrypted, err := ccm1.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, plainText[:c.size], decrypted)
+
+		encrypted[0] ^= 1
+		_, err = ccm1.Decrypt(encrypted)
+		assert.ErrorIs(t, err, ErrAuthFailed)
+	}
+}
+
+func TestCcmInvalidSizes(t *testing.T) {
+	assert.Panics(t, func() { NewAes(key, iv).CCM().NonceSize(6) })
+	assert.Panics(t, func() { NewAes(key, iv).CCM().NonceSize(14) })
+	assert.Panics(t, func() { NewAes(key, iv).CCM().TagSize(7) })
+}
@@ -0,0 +1,137 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+var ErrSegmentSize = errors.New("cfb: segment size must be 1 or a multiple of 8 bits up to the block size")
+
+// cfbSegmentEncryptor NIST SP 800-38A CFB-s, 每次用加密结果的高 s 位加解密,
+// 再把 s 位密文移入寄存器. CFB8 对应 Java 的 AES/CFB8/NoPadding
+type cfbSegmentEncryptor struct {
+	block cipher.Block
+	iv    []byte
+	bits  int
+}
+
+func newCfbSegmentEncryptor(block cipher.Block, iv []byte, bits int) *cfbSegmentEncryptor {
+	return &cfbSegmentEncryptor{block: block, iv: iv, bits: bits}
+}
+
+func validSegment(bits, blockSize int) bool {
+	return bits == 1 || (bits%8 == 0 && bits > 0 && bits <= blockSize*8)
+}
+
+func (c cfbSegmentEncryptor) Encrypt(src []byte) (dst []byte, err error) {
+	dst = make([]byte, len(src))
+	c.newState(false).crypt(dst, src)
+	return
+}
+
+func (c cfbSegmentEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	dst = make([]byte, len(src))
+	c.newState(true).crypt(dst, src)
+	return
+}
+
+// stream 每次处理完整的分段, 最后一个分段可以不完整
+func (c cfbSegmentEncryptor) stream(decrypt bool) (streamState, error) {
+	state := c.newState(decrypt)
+	unit := 1
+	if c.bits != 1 {
+		unit = c.bits / 8
+	}
+
+	return &unitStream{unit: unit, crypt: func(dst, src []byte) ([]byte, error) {
+		dst, out := sliceForAppend(dst, len(src))
+		state.crypt(out, src)
+		return dst, nil
+	}}, nil
+}
+
+func (c cfbSegmentEncryptor) newState(decrypt bool) *cfbSegmentState {
+	bs := c.block.BlockSize()
+	state := &cfbSegmentState{
+		block:    c.block,
+		bits:     c.bits,
+		decrypt:  decrypt,
+		register: make([]byte, bs),
+		out:      make([]byte, bs),
+	}
+
+	copy(state.register, c.iv)
+	return state
+}
+
+// cfbSegmentState 移位寄存器在多次调用之间保留
+type cfbSegmentState struct {
+	block    cipher.Block
+	bits     int
+	decrypt  bool
+	register []byte
+	out      []byte
+}
+
+func (c *cfbSegmentState) crypt(dst, src []byte) {
+	if c.bits == 1 {
+		c.cryptBits(dst, src)
+		return
+	}
+
+	bs := c.block.BlockSize()
+	segment := c.bits / 8
+	for len(src) > 0 {
+		c.block.Encrypt(c.out, c.register)
+
+		n := segment
+		if n > len(src) {
+			n = len(src)
+		}
+
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ c.out[i]
+		}
+
+		feedback := dst[:n]
+		if c.decrypt {
+			feedback = src[:n]
+		}
+
+		copy(c.register, c.register[n:])
+		copy(c.register[bs-n:], feedback)
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// cryptBits CFB1, 每字节按高位在前逐位处理
+func (c *cfbSegmentState) cryptBits(dst, src []byte) {
+	for i := range src {
+		var result byte
+		for bit := 7; bit >= 0; bit-- {
+			c.block.Encrypt(c.out, c.register)
+
+			in := src[i] >> uint(bit) & 1
+			o := in ^ c.out[0]>>7
+			result |= o << uint(bit)
+
+			feedback := o
+			if c.decrypt {
+				feedback = in
+			}
+
+			shiftLeftBit(c.register, feedback)
+		}
+
+		dst[i] = result
+	}
+}
+
+// shiftLeftBit 寄存器整体左移一位, 最低位补 bit
+func shiftLeftBit(register []byte, bit byte) {
+	for i := 0; i < len(register)-1; i++ {
+		register[i] = register[i]<<1 | register[i+1]>>7
+	}
+
+	register[len(register)-1] = register[len(register)-1]<<1 | bit
+}
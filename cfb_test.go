@@ -0,0 +1,80 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCfbSegment(t *testing.T) {
+	for _, bits := range []int{1, 8, 64, 128} {
+		cfb1 := NewAes(key, iv).CFBSegment(bits).Base64()
+		testMethod(t, cfb1, false, nil)
+	}
+
+	// CFB128 与标准库实现一致
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	assert.Equal(t, mustEncrypt(t, NewAes(key, iv).CFB(), text), mustEncrypt(t, NewAes(key, iv).CFBSegment(128), text))
+
+	assert.Panics(t, func() { NewAes(key, iv).CFBSegment(12) })
+	assert.Panics(t, func() { NewAes(key, iv).CFBSegment(256) })
+}
+
+func TestCfb8Vector(t *testing.T) {
+	// NIST SP 800-38A F.3.7 CFB8-AES128.Encrypt, 与 Java AES/CFB8/NoPadding 一致
+	nistKey := []byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c}
+	nistIv := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	text := []byte{0x6b, 0xc1, 0xbe, 0xe2, 0x2e, 0x40, 0x9f, 0x96, 0xe9, 0x3d, 0x7e, 0x11, 0x73, 0x93, 0x17, 0x2a, 0xae, 0x2d}
+
+	cfb8 := NewAes(nistKey, nistIv).CFB8().Hex()
+	encrypted := mustEncrypt(t, cfb8, text)
+	assert.Equal(t, "3b79424c9c0dd436bace9e0ed4586a4f32b9", string(encrypted))
+
+	decrypted, err := cfb8.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+
+	// F.3.1 CFB1-AES128.Encrypt 前 16 位
+	cfb1 := NewAes(nistKey, nistIv).CFBSegment(1).Hex()
+	assert.Equal(t, "68b3", string(mustEncrypt(t, cfb1, text[:2])))
+}
+
+func TestCfb64Vector(t *testing.T) {
+	// FIPS 81 附录 D 表 D2 的 DES CFB64, 与 openssl des-cfb 一致
+	desKey, _ := hex.DecodeString("0123456789abcdef")
+	desIv, _ := hex.DecodeString("1234567890abcdef")
+	text := []byte("Now is the time for all ")
+
+	cfb64 := NewDes(desKey, desIv).CFBSegment(64).Hex()
+	encrypted := mustEncrypt(t, cfb64, text)
+	assert.Equal(t, "f3096249c7f46e51a69e839b1a92f78403467133898ea622", string(encrypted))
+
+	decrypted, err := cfb64.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+
+	// SP 800-38A 没有 AES CFB64 的向量, 按 6.3 节的定义逐段计算作为对照, 最后一段不完整
+	block, _ := aes.NewCipher(key)
+	text = bytes.Repeat([]byte("cfb64 segment"), 7)
+	expected := make([]byte, len(text))
+	register, out := append([]byte(nil), iv...), make([]byte, aes.BlockSize)
+	for i := 0; i < len(text); i += 8 {
+		block.Encrypt(out, register)
+		end := i + 8
+		if end > len(text) {
+			end = len(text)
+		}
+
+		for j := i; j < end; j++ {
+			expected[j] = text[j] ^ out[j-i]
+		}
+
+		register = append(register[8:], expected[i:end]...)
+	}
+
+	cfb64 = NewAes(key, iv).CFBSegment(64)
+	assert.Equal(t, expected, mustEncrypt(t, cfb64, text))
+}
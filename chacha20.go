@@ -0,0 +1,105 @@
+package encrypt
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	chachaKeySize   = 32
+	chachaBlockSize = 64
+
+	// chachaMaxBlocks 32 位计数器最多能生成的块数
+	chachaMaxBlocks = 1 << 32
+)
+
+var chachaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+func chachaQuarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+	return a, b, c, d
+}
+
+func chachaRounds(x *[16]uint32) {
+	for i := 0; i < 10; i++ {
+		x[0], x[4], x[8], x[12] = chachaQuarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = chachaQuarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = chachaQuarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = chachaQuarterRound(x[3], x[7], x[11], x[15])
+
+		x[0], x[5], x[10], x[15] = chachaQuarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = chachaQuarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = chachaQuarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = chachaQuarterRound(x[3], x[4], x[9], x[14])
+	}
+}
+
+func chachaInitState(key []byte) (state [16]uint32) {
+	copy(state[:4], chachaConstants[:])
+	for i := 0; i < 8; i++ {
+		state[4+i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+
+	return
+}
+
+// chacha20XORKeyStream RFC 8439 的 ChaCha20, 12 字节 nonce, 32 位计数器
+// 计数器回绕会重复使用密钥流, 超出时 panic
+func chacha20XORKeyStream(dst, src, key, nonce []byte, counter uint32) {
+	if uint64(counter)+(uint64(len(src))+chachaBlockSize-1)/chachaBlockSize > chachaMaxBlocks {
+		panic("chacha20: counter overflow")
+	}
+
+	state := chachaInitState(key)
+	state[13] = binary.LittleEndian.Uint32(nonce[0:])
+	state[14] = binary.LittleEndian.Uint32(nonce[4:])
+	state[15] = binary.LittleEndian.Uint32(nonce[8:])
+
+	var x [16]uint32
+	var stream [chachaBlockSize]byte
+	for len(src) > 0 {
+		state[12] = counter
+		x = state
+		chachaRounds(&x)
+		for i := range x {
+			binary.LittleEndian.PutUint32(stream[i*4:], x[i]+state[i])
+		}
+
+		n := len(src)
+		if n > chachaBlockSize {
+			n = chachaBlockSize
+		}
+
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ stream[i]
+		}
+
+		dst, src = dst[n:], src[n:]
+		counter++
+	}
+}
+
+// hChaCha20 由 key 和 16 字节 nonce 派生 XChaCha20 的子密钥
+func hChaCha20(key, nonce []byte) []byte {
+	x := chachaInitState(key)
+	for i := 0; i < 4; i++ {
+		x[12+i] = binary.LittleEndian.Uint32(nonce[i*4:])
+	}
+
+	chachaRounds(&x)
+
+	out := make([]byte, chachaKeySize)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(out[i*4:], x[i])
+		binary.LittleEndian.PutUint32(out[16+i*4:], x[12+i])
+	}
+
+	return out
+}
@@ -0,0 +1,183 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	chachaNonceSize  = 12
+	xchachaNonceSize = 24
+)
+
+// chacha20Poly1305 RFC 8439 AEAD
+type chacha20Poly1305 struct {
+	key []byte
+}
+
+func newChaCha20Poly1305(key []byte) (cipher.AEAD, error) {
+	if len(key) != chachaKeySize {
+		return nil, &KeyError{Algorithm: "chacha20poly1305", Size: len(key)}
+	}
+
+	return &chacha20Poly1305{key: append([]byte(nil), key...)}, nil
+}
+
+func (c *chacha20Poly1305) NonceSize() int {
+	return chachaNonceSize
+}
+
+func (c *chacha20Poly1305) Overhead() int {
+	return poly1305TagSize
+}
+
+func (c *chacha20Poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != chachaNonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Seal")
+	}
+
+	// 计数器从 1 开始, 明文最多 (2^32-1)*64 字节
+	if uint64(len(plaintext)) > (chachaMaxBlocks-1)*chachaBlockSize {
+		panic("chacha20poly1305: plaintext too large")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305TagSize)
+	chacha20XORKeyStream(out, plaintext, c.key, nonce, 1)
+	chachaPoly1305Tag(out[len(plaintext):len(plaintext)], c.key, nonce, additionalData, out[:len(plaintext)])
+	return ret
+}
+
+func (c *chacha20Poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != chachaNonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Open")
+	}
+
+	if len(ciphertext) < poly1305TagSize {
+		return nil, ErrAuthFailed
+	}
+
+	if uint64(len(ciphertext)) > (chachaMaxBlocks-1)*chachaBlockSize+poly1305TagSize {
+		panic("chacha20poly1305: ciphertext too large")
+	}
+
+	tag := ciphertext[len(ciphertext)-poly1305TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305TagSize]
+
+	expected := chachaPoly1305Tag(nil, c.key, nonce, additionalData, ciphertext)
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		return nil, ErrAuthFailed
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	chacha20XORKeyStream(out, ciphertext, c.key, nonce, 1)
+	return ret, nil
+}
+
+func chachaPoly1305Tag(out, key, nonce, additionalData, ciphertext []byte) []byte {
+	var polyKey [poly1305KeySize]byte
+	chacha20XORKeyStream(polyKey[:], polyKey[:], key, nonce, 0)
+
+	mac := newPoly1305(polyKey[:])
+	mac.Write(additionalData)
+	mac.pad16()
+	mac.Write(ciphertext)
+	mac.pad16()
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[0:], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(ciphertext)))
+	mac.Write(lengths[:])
+	return mac.Sum(out)
+}
+
+// xchacha20Poly1305 24 字节 nonce, 前 16 字节经 HChaCha20 派生子密钥
+type xchacha20Poly1305 struct {
+	key []byte
+}
+
+func newXChaCha20Poly1305(key []byte) (cipher.AEAD, error) {
+	if len(key) != chachaKeySize {
+		return nil, &KeyError{Algorithm: "xchacha20poly1305", Size: len(key)}
+	}
+
+	return &xchacha20Poly1305{key: append([]byte(nil), key...)}, nil
+}
+
+func (x *xchacha20Poly1305) NonceSize() int {
+	return xchachaNonceSize
+}
+
+func (x *xchacha20Poly1305) Overhead() int {
+	return poly1305TagSize
+}
+
+func (x *xchacha20Poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != xchachaNonceSize {
+		panic("xchacha20poly1305: bad nonce length passed to Seal")
+	}
+
+	c, chachaNonce := x.derive(nonce)
+	return c.Seal(dst, chachaNonce, plaintext, additionalData)
+}
+
+func (x *xchacha20Poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != xchachaNonceSize {
+		panic("xchacha20poly1305: bad nonce length passed to Open")
+	}
+
+	c, chachaNonce := x.derive(nonce)
+	return c.Open(dst, chachaNonce, ciphertext, additionalData)
+}
+
+func (x *xchacha20Poly1305) derive(nonce []byte) (*chacha20Poly1305, []byte) {
+	chachaNonce := make([]byte, chachaNonceSize)
+	copy(chachaNonce[4:], nonce[16:])
+	return &chacha20Poly1305{key: hChaCha20(x.key, nonce[:16])}, chachaNonce
+}
+
+func chachaAeadFactory(key []byte, nonceSize int, newAead func([]byte) (cipher.AEAD, error)) aeadFactory {
+	return func(_ cipher.Block, size, tagSize int) (cipher.AEAD, error) {
+		if size != nonceSize {
+			return nil, errors.New("chacha20poly1305: invalid nonce size")
+		}
+
+		if tagSize != poly1305TagSize {
+			return nil, errors.New("chacha20poly1305: tag size must be 16")
+		}
+
+		return newAead(key)
+	}
+}
+
+func NewChaCha20Poly1305(key, nonce []byte) IAead {
+	return must(TryNewChaCha20Poly1305(key, nonce))
+}
+
+func NewXChaCha20Poly1305(key, nonce []byte) IAead {
+	return must(TryNewXChaCha20Poly1305(key, nonce))
+}
+
+func TryNewChaCha20Poly1305(key, nonce []byte) (IAead, error) {
+	m := NewMethod(nil, nonce)
+	return newAead(m, "chacha20poly1305", chachaAeadFactory(key, chachaNonceSize, newChaCha20Poly1305), chachaNonceSize, poly1305TagSize)
+}
+
+func TryNewXChaCha20Poly1305(key, nonce []byte) (IAead, error) {
+	m := NewMethod(nil, nonce)
+	return newAead(m, "xchacha20poly1305", chachaAeadFactory(key, xchachaNonceSize, newXChaCha20Poly1305), xchachaNonceSize, poly1305TagSize)
+}
+
+// sliceForAppend 扩展 in 以容纳 n 个字节, 返回整体切片和新增部分
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+
+	tail = head[len(in):]
+	return
+}
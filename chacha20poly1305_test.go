@@ -0,0 +1,62 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	chachaKey, _        = hex.DecodeString("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	chachaAdditional, _ = hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+	chachaPlainText     = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+)
+
+func TestPoly1305(t *testing.T) {
+	// RFC 8439 2.5.2
+	polyKey, _ := hex.DecodeString("85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b")
+	mac := newPoly1305(polyKey)
+	mac.Write([]byte("Cryptographic Forum Research Group"))
+	assert.Equal(t, "a8061dc1305136c6c22b8baf0c0127a9", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestChaCha20Counter(t *testing.T) {
+	nonce := make([]byte, chachaNonceSize)
+	src := make([]byte, 2*chachaBlockSize)
+
+	// 计数器最后一个值还能生成一块
+	chacha20XORKeyStream(src[:chachaBlockSize], src[:chachaBlockSize], chachaKey, nonce, 1<<32-1)
+	chacha20XORKeyStream(src[:chachaBlockSize+1], src[:chachaBlockSize+1], chachaKey, nonce, 1<<32-2)
+
+	assert.PanicsWithValue(t, "chacha20: counter overflow", func() {
+		chacha20XORKeyStream(src[:chachaBlockSize+1], src[:chachaBlockSize+1], chachaKey, nonce, 1<<32-1)
+	})
+}
+
+func TestChaCha20Poly1305(t *testing.T) {
+	// RFC 8439 2.8.2
+	nonce, _ := hex.DecodeString("070000004041424344454647")
+	chacha := NewChaCha20Poly1305(chachaKey, nonce).Additional(chachaAdditional).Hex()
+	testMethod(t, chacha, false, nil)
+
+	encrypted := mustEncrypt(t, chacha, chachaPlainText)
+	assert.Equal(t, "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d63dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b3692ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc3ff4def08e4b7a9de576d26586cec64b61161ae10b594f09e26a7e902ecbd0600691", string(encrypted))
+
+	encrypted[0] ^= 1
+	_, err := chacha.Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestXChaCha20Poly1305(t *testing.T) {
+	// draft-irtf-cfrg-xchacha A.3.1
+	nonce, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f5051525354555657")
+	xchacha := NewXChaCha20Poly1305(chachaKey, nonce).Additional(chachaAdditional).Hex()
+	testMethod(t, xchacha, false, nil)
+
+	encrypted := mustEncrypt(t, xchacha, chachaPlainText)
+	assert.Equal(t, "bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52ec0875924c1c7987947deafd8780acf49", string(encrypted))
+
+	_, err := NewXChaCha20Poly1305(chachaKey, nonce).Hex().Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+}
@@ -0,0 +1,393 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	chunkedSaltSize   = 32
+	chunkedPrefixSize = 7
+	chunkedHeaderSize = chunkedSaltSize + chunkedPrefixSize
+	chunkedNonceSize  = chunkedPrefixSize + 4 + 1
+	chunkedTagSize    = 16
+	chunkedMaxSegment = 1 << 30
+)
+
+var (
+	ErrChunkedSegmentSize = errors.New("chunked: segment size must be between 1 and 1GiB")
+	ErrChunkedTooLong     = errors.New("chunked: too many segments")
+	ErrChunkedTruncated   = errors.New("chunked: ciphertext truncated")
+)
+
+// IChunked 分段认证加密的文件格式 (STREAM 构造, 与 Tink 的 AES-GCM-HKDF 流式加密相同思路):
+//
+//	header: 32 字节随机 salt || 7 字节随机 nonce 前缀
+//	segment: AES-GCM(明文分段), nonce = 前缀 || 大端 4 字节序号 || 最后一段标记
+//
+// 每个文件使用 HKDF-SHA256(key, salt) 派生的子密钥, 不同文件之间 nonce 不会重复.
+// 子密钥与 key 等长, 同一个 key 建议加密不超过 2^48 个文件, 单个文件最多 2^32 段.
+// 每段明文 segmentSize 字节, 最后一段可以更短. 序号与最后一段标记保证
+// 分段被截断, 重排或替换时都无法通过认证
+type IChunked interface {
+	Additional(data []byte) IChunked
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) io.Reader
+	NewReaderAt(r io.ReaderAt, size int64) (*ChunkedReaderAt, error)
+}
+
+type Chunked struct {
+	key         []byte
+	newCipher   cipherFunc
+	segmentSize int
+	additional  []byte
+}
+
+func newChunked(key []byte, newCipher cipherFunc, segmentSize int) (*Chunked, error) {
+	if segmentSize <= 0 || segmentSize > chunkedMaxSegment {
+		return nil, ErrChunkedSegmentSize
+	}
+
+	c := &Chunked{key: key, newCipher: newCipher, segmentSize: segmentSize}
+	// 提前检查 key 能否创建 GCM
+	if _, err := c.derive(make([]byte, chunkedHeaderSize)); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// chunkedFile 单个文件的子密钥和 nonce 前缀
+type chunkedFile struct {
+	aead   cipher.AEAD
+	prefix []byte
+}
+
+// derive 由 header 中的 salt 派生该文件的 AES-GCM
+func (c *Chunked) derive(header []byte) (*chunkedFile, error) {
+	block, err := c.newCipher(hkdfSha256(c.key, header[:chunkedSaltSize], []byte("chunked-gcm"), len(c.key)))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunkedFile{aead: aead, prefix: header[chunkedSaltSize:]}, nil
+}
+
+// hkdfSha256 RFC 5869
+func hkdfSha256(secret, salt, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	var out, t []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		expand.Reset()
+		expand.Write(t)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		t = expand.Sum(t[:0])
+		out = append(out, t...)
+	}
+
+	return out[:length]
+}
+
+// Additional 每一段都使用的关联数据
+func (c *Chunked) Additional(data []byte) IChunked {
+	d := *c
+	d.additional = append([]byte(nil), data...)
+	return &d
+}
+
+// NewWriter 写入的明文按段加密后写到 w, 必须调用 Close 写出最后一段. Close 不会关闭 w
+func (c *Chunked) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	header := make([]byte, chunkedHeaderSize)
+	if _, err := io.ReadFull(rand.Reader, header); err != nil {
+		return nil, err
+	}
+
+	file, err := c.derive(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &chunkedWriter{Chunked: c, w: w, file: file}, nil
+}
+
+// NewReader 顺序解密, 每一段通过认证后才会输出
+func (c *Chunked) NewReader(r io.Reader) io.Reader {
+	return &chunkedReader{Chunked: c, r: r}
+}
+
+// NewReaderAt 随机读取, size 为密文总长度. 创建时校验最后一段, 可以立即发现截断
+func (c *Chunked) NewReaderAt(r io.ReaderAt, size int64) (*ChunkedReaderAt, error) {
+	segment := int64(c.segmentSize + chunkedTagSize)
+	body := size - chunkedHeaderSize
+	if body < chunkedTagSize {
+		return nil, ErrChunkedTruncated
+	}
+
+	count := (body + segment - 1) / segment
+	if last := body - (count-1)*segment; last < chunkedTagSize {
+		return nil, ErrChunkedTruncated
+	}
+
+	if count > math.MaxUint32 {
+		return nil, ErrChunkedTooLong
+	}
+
+	header := make([]byte, chunkedHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+
+	file, err := c.derive(header)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &ChunkedReaderAt{
+		Chunked: c,
+		r:       r,
+		file:    file,
+		count:   count,
+		body:    body,
+		size:    body - count*chunkedTagSize,
+	}
+
+	if _, err := reader.segment(reader.buffer(), nil, count-1); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+func (c *Chunked) nonce(dst, prefix []byte, index uint32, last bool) []byte {
+	dst = append(dst[:0], prefix...)
+	dst = binary.BigEndian.AppendUint32(dst, index)
+	if last {
+		return append(dst, 1)
+	}
+
+	return append(dst, 0)
+}
+
+func (c *Chunked) seal(dst, nonce []byte, file *chunkedFile, index uint32, last bool, plain []byte) []byte {
+	return file.aead.Seal(dst, c.nonce(nonce, file.prefix, index, last), plain, c.additional)
+}
+
+func (c *Chunked) open(dst, nonce []byte, file *chunkedFile, index uint32, last bool, crypto []byte) ([]byte, error) {
+	plain, err := file.aead.Open(dst, c.nonce(nonce, file.prefix, index, last), crypto, c.additional)
+	if err != nil {
+		return nil, &AuthError{Mode: "chunked"}
+	}
+
+	return plain, nil
+}
+
+type chunkedWriter struct {
+	*Chunked
+	w      io.Writer
+	file   *chunkedFile
+	nonce  []byte
+	index  uint32
+	buf    []byte
+	out    []byte
+	err    error
+	closed bool
+}
+
+// Write 缓存至少一个字节之后才写出整段, 这样 Close 时总有最后一段
+func (c *chunkedWriter) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	c.buf = append(c.buf, p...)
+	written := 0
+	for len(c.buf)-written > c.segmentSize {
+		if c.err = c.flush(c.buf[written:written+c.segmentSize], false); c.err != nil {
+			return 0, c.err
+		}
+
+		written += c.segmentSize
+	}
+
+	c.buf = c.buf[:copy(c.buf, c.buf[written:])]
+	return len(p), nil
+}
+
+func (c *chunkedWriter) Close() error {
+	if c.closed {
+		return c.err
+	}
+
+	c.closed = true
+	if c.err == nil {
+		c.err = c.flush(c.buf, true)
+	}
+
+	return c.err
+}
+
+func (c *chunkedWriter) flush(plain []byte, last bool) error {
+	if c.index == math.MaxUint32 && !last {
+		return ErrChunkedTooLong
+	}
+
+	c.out = c.seal(c.out[:0], c.nonce, c.file, c.index, last, plain)
+	c.index++
+	_, err := c.w.Write(c.out)
+	return err
+}
+
+type chunkedReader struct {
+	*Chunked
+	r     io.Reader
+	file  *chunkedFile
+	nonce []byte
+	index uint32
+	buf   []byte
+	plain []byte
+	out   []byte
+	err   error
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for len(c.plain) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+
+		c.next()
+	}
+
+	n := copy(p, c.plain)
+	c.plain = c.plain[n:]
+	return n, nil
+}
+
+// next 多读一个字节判断当前段是否为最后一段
+func (c *chunkedReader) next() {
+	if c.file == nil {
+		header := make([]byte, chunkedHeaderSize)
+		if _, err := io.ReadFull(c.r, header); err != nil {
+			c.err = ErrChunkedTruncated
+			return
+		}
+
+		if c.file, c.err = c.derive(header); c.err != nil {
+			return
+		}
+
+		c.buf = make([]byte, 0, c.segmentSize+chunkedTagSize+1)
+	}
+
+	n, err := io.ReadFull(c.r, c.buf[len(c.buf):cap(c.buf)])
+	c.buf = c.buf[:len(c.buf)+n]
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		c.err = err
+		return
+	}
+
+	last := err != nil
+	segment := c.buf
+	if !last {
+		if c.index == math.MaxUint32 {
+			c.err = ErrChunkedTooLong
+			return
+		}
+
+		segment = c.buf[:len(c.buf)-1]
+	}
+
+	if c.out, c.err = c.open(c.out[:0], c.nonce, c.file, c.index, last, segment); c.err != nil {
+		return
+	}
+
+	c.plain = c.out
+	c.index++
+	c.buf = c.buf[:copy(c.buf, c.buf[len(segment):])]
+	if last {
+		c.err = io.EOF
+	}
+}
+
+// ChunkedReaderAt 按需读取并校验涉及的分段, 可以并发调用 ReadAt
+type ChunkedReaderAt struct {
+	*Chunked
+	r     io.ReaderAt
+	file  *chunkedFile
+	count int64
+	body  int64
+	size  int64
+}
+
+// Size 明文长度
+func (c *ChunkedReaderAt) Size() int64 {
+	return c.size
+}
+
+func (c *ChunkedReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("chunked: negative offset")
+	}
+
+	var plain []byte
+	crypto := c.buffer()
+	for n < len(p) && off < c.size {
+		index := off / int64(c.segmentSize)
+		if plain, err = c.segment(crypto, plain[:0], index); err != nil {
+			return
+		}
+
+		copied := copy(p[n:], plain[off-index*int64(c.segmentSize):])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		err = io.EOF
+	}
+
+	return
+}
+
+func (c *ChunkedReaderAt) buffer() []byte {
+	return make([]byte, c.segmentSize+chunkedTagSize)
+}
+
+// segment 用 crypto 作为缓冲区读取第 index 段, 解密后追加到 plain
+func (c *ChunkedReaderAt) segment(crypto, plain []byte, index int64) ([]byte, error) {
+	length := int64(c.segmentSize + chunkedTagSize)
+	start := chunkedHeaderSize + index*length
+	if index == c.count-1 {
+		length = c.body - index*length
+	}
+
+	crypto = crypto[:length]
+	if _, err := c.r.ReadAt(crypto, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return c.open(plain, make([]byte, 0, chunkedNonceSize), c.file, uint32(index), index == c.count-1, crypto)
+}
@@ -0,0 +1,174 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSegmentSize = 64
+
+func chunkedEncrypt(t *testing.T, chunked IChunked, text []byte, chunk int) []byte {
+	var buf bytes.Buffer
+	writer, err := chunked.NewWriter(&buf)
+	assert.NoError(t, err)
+
+	for rest := text; len(rest) > 0; {
+		n := chunk
+		if n > len(rest) {
+			n = len(rest)
+		}
+
+		_, err = writer.Write(rest[:n])
+		assert.NoError(t, err)
+		rest = rest[n:]
+	}
+
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestChunked(t *testing.T) {
+	chunked := NewAes(key, nil).ChunkedGCM(testSegmentSize).Additional([]byte("header"))
+	random := rand.New(rand.NewSource(1))
+
+	for _, size := range []int{0, 1, 63, 64, 65, 128, 1000, 10000} {
+		text := make([]byte, size)
+		random.Read(text)
+
+		for _, chunk := range []int{1, 10, 64, 4096} {
+			encrypted := chunkedEncrypt(t, chunked, text, chunk)
+			segments := (size + testSegmentSize - 1) / testSegmentSize
+			if segments == 0 {
+				segments = 1
+			}
+
+			assert.Len(t, encrypted, chunkedHeaderSize+size+segments*chunkedTagSize)
+
+			decrypted, err := io.ReadAll(chunked.NewReader(iotest.HalfReader(bytes.NewReader(encrypted))))
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(text, decrypted), "size %d chunk %d", size, chunk)
+		}
+	}
+}
+
+func TestChunkedReaderAt(t *testing.T) {
+	chunked := NewAes(key, nil).ChunkedGCM(testSegmentSize)
+	text := make([]byte, 1000)
+	rand.New(rand.NewSource(2)).Read(text)
+	encrypted := chunkedEncrypt(t, chunked, text, 333)
+
+	reader, err := chunked.NewReaderAt(bytes.NewReader(encrypted), int64(len(encrypted)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(text)), reader.Size())
+
+	random := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		off := random.Intn(len(text))
+		p := make([]byte, random.Intn(300)+1)
+
+		n, err := reader.ReadAt(p, int64(off))
+		if off+len(p) > len(text) {
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, len(text)-off, n)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, len(p), n)
+		}
+
+		assert.Equal(t, text[off:off+n], p[:n])
+	}
+
+	// 配合 io.SectionReader 实现 Seek
+	section := io.NewSectionReader(reader, 0, reader.Size())
+	_, err = section.Seek(500, io.SeekStart)
+	assert.NoError(t, err)
+	rest, err := io.ReadAll(section)
+	assert.NoError(t, err)
+	assert.Equal(t, text[500:], rest)
+
+	n, err := reader.ReadAt(make([]byte, 10), int64(len(text)))
+	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, io.EOF)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(off int) {
+			defer wg.Done()
+			p := make([]byte, 100)
+			_, err := reader.ReadAt(p, int64(off))
+			assert.NoError(t, err)
+			assert.Equal(t, text[off:off+100], p)
+		}(i * 100)
+	}
+
+	wg.Wait()
+}
+
+func TestChunkedTampered(t *testing.T) {
+	chunked := NewAes(key, nil).ChunkedGCM(testSegmentSize)
+	text := make([]byte, 4*testSegmentSize)
+	encrypted := chunkedEncrypt(t, chunked, text, len(text))
+	segment := testSegmentSize + chunkedTagSize
+
+	swapped := bytes.Clone(encrypted)
+	copy(swapped[chunkedHeaderSize:], encrypted[chunkedHeaderSize+segment:chunkedHeaderSize+2*segment])
+	copy(swapped[chunkedHeaderSize+segment:], encrypted[chunkedHeaderSize:chunkedHeaderSize+segment])
+
+	cases := map[string][]byte{
+		// 在分段边界截断
+		"truncated": encrypted[:chunkedHeaderSize+2*segment],
+		"reordered": swapped,
+		"appended":  append(bytes.Clone(encrypted), encrypted[chunkedHeaderSize:chunkedHeaderSize+segment]...),
+		"empty":     encrypted[:chunkedHeaderSize],
+	}
+
+	for name, data := range cases {
+		_, err := io.ReadAll(chunked.NewReader(bytes.NewReader(data)))
+		assert.Error(t, err, name)
+
+		reader, err := chunked.NewReaderAt(bytes.NewReader(data), int64(len(data)))
+		if err == nil {
+			_, err = reader.ReadAt(make([]byte, len(text)), 0)
+		}
+
+		assert.Error(t, err, name)
+	}
+
+	_, err := io.ReadAll(chunked.NewReader(bytes.NewReader(encrypted[:3])))
+	assert.ErrorIs(t, err, ErrChunkedTruncated)
+
+	_, err = io.ReadAll(chunked.Additional([]byte("other")).NewReader(bytes.NewReader(encrypted)))
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	_, err = NewAes(key, nil).TryChunkedGCM(0)
+	assert.ErrorIs(t, err, ErrChunkedSegmentSize)
+}
+
+func TestChunkedSubKey(t *testing.T) {
+	// RFC 5869 A.1
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	okm := hkdfSha256(bytes.Repeat([]byte{0x0b}, 22), salt, info, 42)
+	assert.Equal(t, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865", hex.EncodeToString(okm))
+
+	// 每个文件的子密钥由各自的 salt 派生, 换成其他文件的 salt 无法解密
+	chunked := NewAes(key, nil).ChunkedGCM(testSegmentSize)
+	text := []byte("1234567890abcdef")
+	first, second := chunkedEncrypt(t, chunked, text, 16), chunkedEncrypt(t, chunked, text, 16)
+	assert.NotEqual(t, first[:chunkedSaltSize], second[:chunkedSaltSize])
+
+	copy(second, first[:chunkedSaltSize])
+	_, err := io.ReadAll(chunked.NewReader(bytes.NewReader(second)))
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	_, err = NewMethod(nil, nil).TryChunkedGCM(testSegmentSize)
+	assert.ErrorIs(t, err, ErrNoKey)
+}
@@ -0,0 +1,85 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+const cmacBlockSize = 16
+
+// cmac NIST SP 800-38B, 即 OMAC1, 仅支持 128 位分组
+type cmac struct {
+	block  cipher.Block
+	k1, k2 [cmacBlockSize]byte
+	x      [cmacBlockSize]byte
+	buf    [cmacBlockSize]byte
+	n      int
+}
+
+func newCmac(block cipher.Block) *cmac {
+	c := &cmac{block: block}
+	block.Encrypt(c.k1[:], c.k1[:])
+	c.k1 = cmacDouble(c.k1)
+	c.k2 = cmacDouble(c.k1)
+	return c
+}
+
+// cmacDouble GF(2^128) 上乘 x
+func cmacDouble(in [cmacBlockSize]byte) (out [cmacBlockSize]byte) {
+	var carry byte
+	for i := cmacBlockSize - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+
+	out[cmacBlockSize-1] ^= byte(subtle.ConstantTimeByteEq(carry, 1)) * 0x87
+	return
+}
+
+func (c *cmac) Reset() {
+	c.x = [cmacBlockSize]byte{}
+	c.n = 0
+}
+
+func (c *cmac) Write(data []byte) (int, error) {
+	size := len(data)
+	for len(data) > 0 {
+		// 保留最后一个分组到 Sum 时处理
+		if c.n == cmacBlockSize {
+			subtle.XORBytes(c.x[:], c.x[:], c.buf[:])
+			c.block.Encrypt(c.x[:], c.x[:])
+			c.n = 0
+		}
+
+		n := copy(c.buf[c.n:], data)
+		c.n += n
+		data = data[n:]
+	}
+
+	return size, nil
+}
+
+func (c *cmac) Sum(in []byte) []byte {
+	var last [cmacBlockSize]byte
+	copy(last[:], c.buf[:c.n])
+	if c.n == cmacBlockSize {
+		subtle.XORBytes(last[:], last[:], c.k1[:])
+	} else {
+		last[c.n] = 0x80
+		subtle.XORBytes(last[:], last[:], c.k2[:])
+	}
+
+	subtle.XORBytes(last[:], last[:], c.x[:])
+	c.block.Encrypt(last[:], last[:])
+	return append(in, last[:]...)
+}
+
+func cmacSum(block cipher.Block, data ...[]byte) (out [cmacBlockSize]byte) {
+	mac := newCmac(block)
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	copy(out[:], mac.Sum(nil))
+	return
+}
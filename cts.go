@@ -0,0 +1,174 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// CtsVariant NIST SP 800-38A Addendum 中最后两个密文分组的排列方式
+type CtsVariant int
+
+const (
+	// Cs1 保持 CBC 的顺序, 倒数第二个分组被截断
+	Cs1 CtsVariant = iota
+	// Cs2 只有最后一个分组不完整时才交换最后两个分组
+	Cs2
+	// Cs3 总是交换最后两个分组 (Kerberos)
+	Cs3
+)
+
+var (
+	ErrCtsSize    = errors.New("cts: input must be at least one block")
+	ErrCtsVariant = errors.New("cts: unknown variant")
+)
+
+type ctsEncryptor struct {
+	block   cipher.Block
+	iv      []byte
+	variant CtsVariant
+}
+
+func newCtsEncryptor(block cipher.Block, iv []byte, variant CtsVariant) *ctsEncryptor {
+	return &ctsEncryptor{block: block, iv: iv, variant: variant}
+}
+
+// swap 判断最后两个分组是否需要交换
+func (c ctsEncryptor) swap(last int) bool {
+	switch c.variant {
+	case Cs2:
+		return last != c.block.BlockSize()
+	case Cs3:
+		return true
+	}
+
+	return false
+}
+
+// split 前缀为普通 CBC 部分, 剩余最后两个 (不完整的) 分组
+func (c ctsEncryptor) split(size int) (prefix, last int) {
+	bs := c.block.BlockSize()
+	last = size % bs
+	if last == 0 {
+		last = bs
+	}
+
+	return size - last - bs, last
+}
+
+func (c ctsEncryptor) Encrypt(src []byte) (dst []byte, err error) {
+	bs := c.block.BlockSize()
+	if len(src) < bs {
+		return nil, ErrCtsSize
+	}
+
+	dst = make([]byte, len(src))
+	if len(src) == bs {
+		cipher.NewCBCEncrypter(c.block, c.iv).CryptBlocks(dst, src)
+		return
+	}
+
+	prefix, last := c.split(len(src))
+	tail := make([]byte, 2*bs)
+	copy(tail, src[prefix:])
+
+	blockMode := cipher.NewCBCEncrypter(c.block, c.iv)
+	blockMode.CryptBlocks(dst[:prefix], src[:prefix])
+	blockMode.CryptBlocks(tail, tail)
+
+	penultimate, final := tail[:last], tail[bs:]
+	if c.swap(last) {
+		copy(dst[prefix:], final)
+		copy(dst[prefix+bs:], penultimate)
+	} else {
+		copy(dst[prefix:], penultimate)
+		copy(dst[prefix+last:], final)
+	}
+
+	return dst, nil
+}
+
+func (c ctsEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	bs := c.block.BlockSize()
+	if len(src) < bs {
+		return nil, ErrCtsSize
+	}
+
+	dst = make([]byte, len(src))
+	if len(src) == bs {
+		cipher.NewCBCDecrypter(c.block, c.iv).CryptBlocks(dst, src)
+		return
+	}
+
+	prefix, last := c.split(len(src))
+	var penultimate, final []byte
+	if c.swap(last) {
+		final, penultimate = src[prefix:prefix+bs], src[prefix+bs:]
+	} else {
+		penultimate, final = src[prefix:prefix+last], src[prefix+last:]
+	}
+
+	iv := c.iv
+	if prefix > 0 {
+		iv = src[prefix-bs : prefix]
+		cipher.NewCBCDecrypter(c.block, c.iv).CryptBlocks(dst[:prefix], src[:prefix])
+	}
+
+	// D(C_n) = (P_n || 0) xor C_{n-1}, 由此补全被截断的 C_{n-1}
+	z := make([]byte, bs)
+	c.block.Decrypt(z, final)
+
+	full := make([]byte, bs)
+	copy(full, penultimate)
+	copy(full[last:], z[last:])
+	subtle.XORBytes(dst[prefix+bs:], z[:last], full[:last])
+
+	c.block.Decrypt(full, full)
+	subtle.XORBytes(dst[prefix:], full, iv)
+	return dst, nil
+}
+
+// stream 除最后两个分组外按 CBC 处理, 结束时对剩余部分做密文窃取
+func (c ctsEncryptor) stream(decrypt bool) (streamState, error) {
+	size := c.block.BlockSize()
+	cbc, err := cbcEncryptor{block: c.block, iv: c.iv}.stream(decrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := cbc.(*unitStream)
+	iv := make([]byte, size)
+	copy(iv, c.iv)
+	return &unitStream{
+		unit: size,
+		tail: size + 1,
+		crypt: func(dst, src []byte) ([]byte, error) {
+			if dst, err = chain.update(dst, src); err != nil {
+				return nil, err
+			}
+
+			// 记录 CBC 的链接值供最后两个分组使用
+			if decrypt {
+				copy(iv, src[len(src)-size:])
+			} else {
+				copy(iv, dst[len(dst)-size:])
+			}
+
+			return dst, nil
+		},
+		last: func(dst, src []byte) ([]byte, error) {
+			last := ctsEncryptor{block: c.block, iv: iv, variant: c.variant}
+			crypt := last.Encrypt
+			if decrypt {
+				crypt = last.Decrypt
+			}
+
+			out, err := crypt(src)
+			if err != nil {
+				return nil, err
+			}
+
+			return append(dst, out...), nil
+		},
+	}, nil
+}
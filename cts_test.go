@@ -0,0 +1,71 @@
+package encrypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCbcCts(t *testing.T) {
+	for _, variant := range []CtsVariant{Cs1, Cs2, Cs3} {
+		cts := NewAes(key, iv).CBCCTS(variant).Base64()
+
+		// 不足一个分组的输入无法窃取密文, 这里只使用确定长度的输入
+		for _, size := range []int{16, 17, 31, 32, 33, 100, 999} {
+			text := bytes.Repeat([]byte("1234567890abcdefghijklmnopqrstuvwxyz"), 28)[:size]
+			assert.Len(t, mustEncrypt(t, NewAes(key, iv).CBCCTS(variant), text), size)
+
+			decrypted, err := cts.Decrypt(mustEncrypt(t, cts, text))
+			assert.NoError(t, err)
+			assert.Equal(t, text, decrypted)
+		}
+
+		_, err := cts.Encrypt([]byte("short"))
+		assert.ErrorIs(t, err, ErrCtsSize)
+
+		_, err = NewAes(key, iv).CBCCTS(variant).Decrypt(make([]byte, 15))
+		assert.ErrorIs(t, err, ErrCtsSize)
+	}
+}
+
+func TestCbcCtsRfc3962(t *testing.T) {
+	// RFC 3962 Appendix B, 即 CBC-CS3 且 iv 为零
+	ctsKey := []byte("chicken teriyaki")
+	cases := []struct {
+		text   string
+		result string
+	}{
+		{"I would like the ", "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{"I would like the General Gau's ", "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{"I would like the General Gau's C", "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+	}
+
+	for _, c := range cases {
+		cts := NewAes(ctsKey, make([]byte, 16)).CBCCTS(Cs3).Hex()
+		encrypted := mustEncrypt(t, cts, []byte(c.text))
+		assert.Equal(t, c.result, string(encrypted))
+
+		decrypted, err := cts.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, c.text, string(decrypted))
+	}
+}
+
+func TestCbcCtsVariants(t *testing.T) {
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	cs1 := mustEncrypt(t, NewAes(key, iv).CBCCTS(Cs1), text)
+	cs2 := mustEncrypt(t, NewAes(key, iv).CBCCTS(Cs2), text)
+	cs3 := mustEncrypt(t, NewAes(key, iv).CBCCTS(Cs3), text)
+
+	// 最后一个分组只有 1 字节, CS2 与 CS3 相同, CS1 把截断的分组放在最后一个分组之前
+	assert.Equal(t, cs2, cs3)
+	assert.Equal(t, cs1[:16], cs3[:16])
+	assert.Equal(t, cs1[16:17], cs3[32:])
+	assert.Equal(t, cs1[17:], cs3[16:32])
+
+	_, err := NewAes(key, iv).CBCCTS(Cs1).Decrypt(text[:15])
+	assert.ErrorIs(t, err, ErrCtsSize)
+	_, err = NewAes(key, iv).CBCCTS(Cs1).Encrypt(text[:15])
+	assert.ErrorIs(t, err, ErrCtsSize)
+}
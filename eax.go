@@ -0,0 +1,94 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+const (
+	eaxBlockSize        = 16
+	eaxDefaultNonceSize = 16
+	eaxDefaultTagSize   = 16
+)
+
+// eax Bellare-Rogaway-Wagner EAX, OMAC 认证 + CTR 加密
+type eax struct {
+	block     cipher.Block
+	nonceSize int
+	tagSize   int
+}
+
+func newEax(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != eaxBlockSize {
+		return nil, errors.New("eax: block size must be 16")
+	}
+
+	if nonceSize < 1 {
+		return nil, errors.New("eax: nonce size must be positive")
+	}
+
+	if tagSize < 1 || tagSize > eaxBlockSize {
+		return nil, errors.New("eax: tag size must be between 1 and 16")
+	}
+
+	return &eax{block: block, nonceSize: nonceSize, tagSize: tagSize}, nil
+}
+
+func (e *eax) NonceSize() int {
+	return e.nonceSize
+}
+
+func (e *eax) Overhead() int {
+	return e.tagSize
+}
+
+func (e *eax) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != e.nonceSize {
+		panic("eax: incorrect nonce length given to EAX")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+e.tagSize)
+	n := e.omac(0, nonce)
+	cipher.NewCTR(e.block, n[:]).XORKeyStream(out, plaintext)
+
+	tag := e.tag(n, out[:len(plaintext)], additionalData)
+	copy(out[len(plaintext):], tag[:e.tagSize])
+	return ret
+}
+
+func (e *eax) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != e.nonceSize {
+		panic("eax: incorrect nonce length given to EAX")
+	}
+
+	if len(ciphertext) < e.tagSize {
+		return nil, ErrAuthFailed
+	}
+
+	size := len(ciphertext) - e.tagSize
+	n := e.omac(0, nonce)
+	expected := e.tag(n, ciphertext[:size], additionalData)
+	if subtle.ConstantTimeCompare(expected[:e.tagSize], ciphertext[size:]) != 1 {
+		return nil, ErrAuthFailed
+	}
+
+	ret, out := sliceForAppend(dst, size)
+	cipher.NewCTR(e.block, n[:]).XORKeyStream(out, ciphertext[:size])
+	return ret, nil
+}
+
+func (e *eax) tag(n [eaxBlockSize]byte, ciphertext, additionalData []byte) (tag [eaxBlockSize]byte) {
+	h := e.omac(1, additionalData)
+	c := e.omac(2, ciphertext)
+	subtle.XORBytes(tag[:], n[:], h[:])
+	subtle.XORBytes(tag[:], tag[:], c[:])
+	return
+}
+
+// omac OMAC^t(M) = CMAC([t]_16 || M)
+func (e *eax) omac(t byte, data []byte) [eaxBlockSize]byte {
+	var prefix [eaxBlockSize]byte
+	prefix[eaxBlockSize-1] = t
+	return cmacSum(e.block, prefix[:], data)
+}
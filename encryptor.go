@@ -2,30 +2,109 @@ package encrypt
 
 import (
 	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
 )
 
+// appendEncryptor 可以把结果直接追加到 dst 的加密器, dst 与 src 可以完全重叠
+type appendEncryptor interface {
+	encryptAppend(dst, src []byte) ([]byte, error)
+	decryptAppend(dst, src []byte) ([]byte, error)
+}
+
+func encryptAppend(encryptor IEncryptor, dst, src []byte) ([]byte, error) {
+	if a, ok := encryptor.(appendEncryptor); ok {
+		return a.encryptAppend(dst, src)
+	}
+
+	crypto, err := encryptor.Encrypt(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, crypto...), nil
+}
+
+func decryptAppend(encryptor IEncryptor, dst, src []byte) ([]byte, error) {
+	if a, ok := encryptor.(appendEncryptor); ok {
+		return a.decryptAppend(dst, src)
+	}
+
+	text, err := encryptor.Decrypt(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, text...), nil
+}
+
 type ecbEncryptor struct {
-	block cipher.Block
+	block     cipher.Block
+	threshold int
 }
 
-func newEcbEncryptor(b cipher.Block) *ecbEncryptor {
-	return &ecbEncryptor{block: b}
+func newEcbEncryptor(b cipher.Block, threshold int) *ecbEncryptor {
+	return &ecbEncryptor{block: b, threshold: threshold}
 }
 
-func (a ecbEncryptor) Encrypt(plainText []byte) (dst []byte) {
+func (a ecbEncryptor) Encrypt(plainText []byte) (dst []byte, err error) {
+	if err = checkFullBlocks(plainText, a.block.BlockSize()); err != nil {
+		return
+	}
+
 	crypto := make([]byte, len(plainText))
-	blockMode := newECBEncrypter(a.block)
-	blockMode.CryptBlocks(crypto, plainText)
-	return crypto
+	parallelize(len(plainText), a.block.BlockSize(), a.threshold, func(start, end int) {
+		newECBEncrypter(a.block).CryptBlocks(crypto[start:end], plainText[start:end])
+	})
+	return crypto, nil
 }
 
 func (a ecbEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	if err = checkFullBlocks(src, a.block.BlockSize()); err != nil {
+		return
+	}
+
 	text := make([]byte, len(src))
-	blockMode := newECBDecrypter(a.block)
-	blockMode.CryptBlocks(text, src)
+	parallelize(len(src), a.block.BlockSize(), a.threshold, func(start, end int) {
+		newECBDecrypter(a.block).CryptBlocks(text[start:end], src[start:end])
+	})
 	return text, nil
 }
 
+func (a ecbEncryptor) encryptAppend(dst, src []byte) ([]byte, error) {
+	return a.cryptAppend(dst, src, a.block.Encrypt)
+}
+
+func (a ecbEncryptor) decryptAppend(dst, src []byte) ([]byte, error) {
+	return a.cryptAppend(dst, src, a.block.Decrypt)
+}
+
+func (a ecbEncryptor) cryptAppend(dst, src []byte, crypt func(dst, src []byte)) ([]byte, error) {
+	size := a.block.BlockSize()
+	if err := checkFullBlocks(src, size); err != nil {
+		return nil, err
+	}
+
+	head, out := sliceForAppend(dst, len(src))
+	parallelize(len(src), size, a.threshold, func(start, end int) {
+		for i := start; i < end; i += size {
+			crypt(out[i:i+size], src[i:i+size])
+		}
+	})
+
+	return head, nil
+}
+
+func (a ecbEncryptor) stream(decrypt bool) (streamState, error) {
+	crypt := a.encryptAppend
+	if decrypt {
+		crypt = a.decryptAppend
+	}
+
+	return &unitStream{unit: a.block.BlockSize(), crypt: crypt}, nil
+}
+
 type cbcEncryptor struct {
 	block cipher.Block
 	iv    []byte
@@ -35,44 +114,129 @@ func newCbcEncryptor(block cipher.Block, iv []byte) *cbcEncryptor {
 	return &cbcEncryptor{block: block, iv: iv}
 }
 
-func (c cbcEncryptor) Encrypt(src []byte) (dst []byte) {
+func (c cbcEncryptor) Encrypt(src []byte) (dst []byte, err error) {
+	if err = checkFullBlocks(src, c.block.BlockSize()); err != nil {
+		return
+	}
+
 	crypto := make([]byte, len(src))
 	blockMode := cipher.NewCBCEncrypter(c.block, c.iv)
 	blockMode.CryptBlocks(crypto, src)
-	return crypto
+	return crypto, nil
 }
 
 func (c cbcEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	if err = checkFullBlocks(src, c.block.BlockSize()); err != nil {
+		return
+	}
+
 	var text = make([]byte, len(src))
 	blockMode := cipher.NewCBCDecrypter(c.block, c.iv)
 	blockMode.CryptBlocks(text, src)
 	return text, nil
 }
 
+func (c cbcEncryptor) encryptAppend(dst, src []byte) ([]byte, error) {
+	size := c.block.BlockSize()
+	if err := checkFullBlocks(src, size); err != nil {
+		return nil, err
+	}
+
+	head, out := sliceForAppend(dst, len(src))
+	prev := c.iv
+	for ; len(src) > 0; out, src = out[size:], src[size:] {
+		subtle.XORBytes(out[:size], src[:size], prev)
+		c.block.Encrypt(out[:size], out[:size])
+		prev = out[:size]
+	}
+
+	return head, nil
+}
+
+// decryptAppend 从最后一个分组向前解密, 原地解密时前一个密文分组仍然可用
+func (c cbcEncryptor) decryptAppend(dst, src []byte) ([]byte, error) {
+	size := c.block.BlockSize()
+	if err := checkFullBlocks(src, size); err != nil {
+		return nil, err
+	}
+
+	head, out := sliceForAppend(dst, len(src))
+	for end := len(src); end > 0; end -= size {
+		start := end - size
+		prev := c.iv
+		if start > 0 {
+			prev = src[start-size : start]
+		}
+
+		c.block.Decrypt(out[start:end], src[start:end])
+		subtle.XORBytes(out[start:end], out[start:end], prev)
+	}
+
+	return head, nil
+}
+
+// stream 每段结束后把最后一个密文分组作为下一段的 iv
+func (c cbcEncryptor) stream(decrypt bool) (streamState, error) {
+	size := c.block.BlockSize()
+	chain := cbcEncryptor{block: c.block, iv: append([]byte(nil), c.iv...)}
+	next := make([]byte, size)
+	return &unitStream{unit: size, crypt: func(dst, src []byte) ([]byte, error) {
+		if err := checkFullBlocks(src, size); err != nil || len(src) == 0 {
+			return dst, err
+		}
+
+		if !decrypt {
+			dst, _ = chain.encryptAppend(dst, src)
+			copy(chain.iv, dst[len(dst)-size:])
+			return dst, nil
+		}
+
+		copy(next, src[len(src)-size:])
+		dst, _ = chain.decryptAppend(dst, src)
+		copy(chain.iv, next)
+		return dst, nil
+	}}, nil
+}
+
 type ctrEncryptor struct {
-	block cipher.Block
-	iv    []byte
+	block     cipher.Block
+	iv        []byte
+	threshold int
 }
 
-func newCtrEncryptor(block cipher.Block, iv []byte) *ctrEncryptor {
-	return &ctrEncryptor{block: block, iv: iv}
+func newCtrEncryptor(block cipher.Block, iv []byte, threshold int) *ctrEncryptor {
+	return &ctrEncryptor{block: block, iv: iv, threshold: threshold}
 }
 
-func (c ctrEncryptor) Encrypt(src []byte) (dst []byte) {
+func (c ctrEncryptor) Encrypt(src []byte) (dst []byte, err error) {
 	crypto := make([]byte, len(src))
-	stream := cipher.NewCTR(c.block, c.iv)
-	stream.XORKeyStream(crypto, src)
-	return crypto
+	c.xor(crypto, src)
+	return crypto, nil
 }
 
 func (c ctrEncryptor) Decrypt(src []byte) (dst []byte, err error) {
-	//TODO implement me
 	var text = make([]byte, len(src))
-	stream := cipher.NewCTR(c.block, c.iv)
-	stream.XORKeyStream(text, src)
+	c.xor(text, src)
 	return text, nil
 }
 
+// xor 并行时每段的计数器从 iv 加上该段之前的分组数开始
+func (c ctrEncryptor) xor(dst, src []byte) {
+	size := c.block.BlockSize()
+	parallelize(len(src), size, c.threshold, func(start, end int) {
+		counter := c.iv
+		if start > 0 {
+			counter = addCounter(c.iv, uint64(start/size))
+		}
+
+		cipher.NewCTR(c.block, counter).XORKeyStream(dst[start:end], src[start:end])
+	})
+}
+
+func (c ctrEncryptor) stream(bool) (streamState, error) {
+	return newXorStream(cipher.NewCTR(c.block, c.iv)), nil
+}
+
 type ofbEncryptor struct {
 	block cipher.Block
 	iv    []byte
@@ -82,11 +246,11 @@ func newOfbEncryptor(block cipher.Block, iv []byte) *ofbEncryptor {
 	return &ofbEncryptor{block: block, iv: iv}
 }
 
-func (o ofbEncryptor) Encrypt(src []byte) (dst []byte) {
+func (o ofbEncryptor) Encrypt(src []byte) (dst []byte, err error) {
 	crypto := make([]byte, len(src))
 	stream := cipher.NewOFB(o.block, o.iv)
 	stream.XORKeyStream(crypto, src)
-	return crypto
+	return crypto, nil
 }
 
 func (o ofbEncryptor) Decrypt(src []byte) (dst []byte, err error) {
@@ -96,6 +260,10 @@ func (o ofbEncryptor) Decrypt(src []byte) (dst []byte, err error) {
 	return text, nil
 }
 
+func (o ofbEncryptor) stream(bool) (streamState, error) {
+	return newXorStream(cipher.NewOFB(o.block, o.iv)), nil
+}
+
 type cfbEncryptor struct {
 	block cipher.Block
 	iv    []byte
@@ -105,11 +273,11 @@ func newCfbEncryptor(block cipher.Block, iv []byte) *cfbEncryptor {
 	return &cfbEncryptor{block: block, iv: iv}
 }
 
-func (c cfbEncryptor) Encrypt(src []byte) (dst []byte) {
+func (c cfbEncryptor) Encrypt(src []byte) (dst []byte, err error) {
 	crypto := make([]byte, len(src))
 	stream := cipher.NewCFBEncrypter(c.block, c.iv)
 	stream.XORKeyStream(crypto, src)
-	return crypto
+	return crypto, nil
 }
 
 func (c cfbEncryptor) Decrypt(src []byte) (dst []byte, err error) {
@@ -118,3 +286,77 @@ func (c cfbEncryptor) Decrypt(src []byte) (dst []byte, err error) {
 	stream.XORKeyStream(text, src)
 	return text, nil
 }
+
+func (c cfbEncryptor) stream(decrypt bool) (streamState, error) {
+	if decrypt {
+		return newXorStream(cipher.NewCFBDecrypter(c.block, c.iv)), nil
+	}
+
+	return newXorStream(cipher.NewCFBEncrypter(c.block, c.iv)), nil
+}
+
+// newXorStream 流模式可以处理任意长度
+func newXorStream(stream cipher.Stream) *unitStream {
+	return &unitStream{unit: 1, crypt: func(dst, src []byte) ([]byte, error) {
+		dst, out := sliceForAppend(dst, len(src))
+		stream.XORKeyStream(out, src)
+		return dst, nil
+	}}
+}
+
+type randomIvEncryptor struct {
+	size  int
+	build func(iv []byte) IEncryptor
+}
+
+func newRandomIvEncryptor(size int, build func(iv []byte) IEncryptor) *randomIvEncryptor {
+	return &randomIvEncryptor{size: size, build: build}
+}
+
+func (r randomIvEncryptor) Encrypt(src []byte) (dst []byte, err error) {
+	iv := make([]byte, r.size)
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return
+	}
+
+	if dst, err = r.build(iv).Encrypt(src); err != nil {
+		return
+	}
+
+	return append(iv, dst...), nil
+}
+
+func (r randomIvEncryptor) Decrypt(src []byte) (dst []byte, err error) {
+	if len(src) < r.size {
+		return nil, ErrIvPrefix
+	}
+
+	return r.build(src[:r.size]).Decrypt(src[r.size:])
+}
+
+func (r randomIvEncryptor) stream(decrypt bool) (streamState, error) {
+	if decrypt {
+		return &randomIvStream{size: r.size, build: r.build}, nil
+	}
+
+	iv := make([]byte, r.size)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	inner, err := newStreamState(r.build(iv), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &randomIvStream{size: r.size, build: r.build, prefix: iv, inner: inner}, nil
+}
+
+// checkFullBlocks 分组模式不做填充时要求输入按分组对齐
+func checkFullBlocks(src []byte, blockSize int) error {
+	if len(src)%blockSize != 0 {
+		return ErrNotFullBlocks
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+const (
+	gcmStandardNonceSize = 12
+	gcmTagSize           = 16
+	gcmMinimumTagSize    = 12
+)
+
+// newGcm 标准库只能单独调整 nonce 或 tag 长度, 两者都不是标准值时使用 gcmTruncated
+func newGcm(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if tagSize == gcmTagSize {
+		return cipher.NewGCMWithNonceSize(block, nonceSize)
+	}
+
+	if nonceSize == gcmStandardNonceSize {
+		return cipher.NewGCMWithTagSize(block, tagSize)
+	}
+
+	if tagSize < gcmMinimumTagSize || tagSize > gcmTagSize {
+		return nil, errors.New("gcm: incorrect tag size given to GCM")
+	}
+
+	aead, err := cipher.NewGCMWithNonceSize(block, nonceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcmTruncated{aead: aead, tagSize: tagSize}, nil
+}
+
+// gcmTruncated 非标准 nonce 加截断的 tag, 结果与 NIST SP 800-38D 一致
+type gcmTruncated struct {
+	aead    cipher.AEAD
+	tagSize int
+}
+
+func (g *gcmTruncated) NonceSize() int {
+	return g.aead.NonceSize()
+}
+
+func (g *gcmTruncated) Overhead() int {
+	return g.tagSize
+}
+
+func (g *gcmTruncated) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	sealed := g.aead.Seal(nil, nonce, plaintext, additionalData)
+	return append(dst, sealed[:len(plaintext)+g.tagSize]...)
+}
+
+// Open 计数器模式与 tag 无关, 先用 Seal 解出明文, 再重新计算完整 tag 比较前 tagSize 个字节
+func (g *gcmTruncated) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < g.tagSize {
+		return nil, ErrAuthFailed
+	}
+
+	tag := ciphertext[len(ciphertext)-g.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-g.tagSize]
+
+	plaintext := g.aead.Seal(nil, nonce, ciphertext, nil)[:len(ciphertext)]
+	expected := g.aead.Seal(nil, nonce, plaintext, additionalData)[len(ciphertext):]
+	if subtle.ConstantTimeCompare(expected[:g.tagSize], tag) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	return append(dst, plaintext...), nil
+}
@@ -0,0 +1,88 @@
+package encrypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGcm(t *testing.T) {
+	gcm1 := NewAes(key, iv).GCM().Additional([]byte("header")).Base64()
+	testMethod(t, gcm1, false, nil)
+}
+
+func TestAeadRandomNonce(t *testing.T) {
+	modes := map[string]func(iv []byte) IAead{
+		"gcm": func(iv []byte) IAead { return NewAes(key, iv).GCM() },
+		"ccm": func(iv []byte) IAead { return NewAes(key, iv).CCM() },
+		"ocb": func(iv []byte) IAead { return NewAes(key, iv).OCB() },
+		"eax": func(iv []byte) IAead { return NewAes(key, iv).EAX() },
+	}
+
+	text := []byte("xq1_ddq")
+	for name, mode := range modes {
+		// 即使传入了 iv, 默认也不重复使用 nonce
+		encryptor := mode(iv)
+		first, second := mustEncrypt(t, encryptor, text), mustEncrypt(t, encryptor, text)
+		assert.NotEqual(t, first, second, name)
+
+		decrypted, err := encryptor.Decrypt(first)
+		assert.NoError(t, err, name)
+		assert.Equal(t, text, decrypted, name)
+
+		// 固定 nonce 需要显式指定, 密文不带前缀, 前缀即为随机 nonce
+		fixed := encryptor.FixedNonce()
+		encrypted := mustEncrypt(t, fixed, text)
+		assert.Equal(t, encrypted, mustEncrypt(t, fixed, text), name)
+
+		size := len(first) - len(encrypted)
+		assert.Equal(t, first[size:], mustEncrypt(t, mode(first[:size]).FixedNonce(), text), name)
+	}
+}
+
+func TestGcmVector(t *testing.T) {
+	// GCM 规范 Test Case 2
+	gcm1 := NewAes(make([]byte, 16), make([]byte, 12)).GCM().FixedNonce().Hex()
+	encrypted := mustEncrypt(t, gcm1, make([]byte, 16))
+	assert.Equal(t, "0388dace60b6a392f328c2b971b2fe78ab6e47d42cec13bdf53a67b21257bddf", string(encrypted))
+}
+
+func TestGcmSizes(t *testing.T) {
+	gcm1 := NewAes(key, iv).GCM().TagSize(12)
+	encrypted := mustEncrypt(t, gcm1, []byte("xq1_ddq"))
+	assert.Len(t, encrypted, gcmStandardNonceSize+7+12)
+	testMethod(t, gcm1, false, nil)
+
+	gcm2 := NewAes(key, iv).GCM().NonceSize(16).Hex()
+	testMethod(t, gcm2, false, nil)
+
+	// 非标准 nonce 和 tag 长度组合时, 结果为完整 tag 的前缀
+	gcm3 := NewAes(key, iv).GCM().NonceSize(8).TagSize(12).FixedNonce().Additional([]byte("header"))
+	testMethod(t, gcm3, false, nil)
+
+	full := mustEncrypt(t, NewAes(key, iv).GCM().NonceSize(8).FixedNonce().Additional([]byte("header")), []byte("xq1_ddq"))
+	encrypted = mustEncrypt(t, gcm3, []byte("xq1_ddq"))
+	assert.Equal(t, full[:7+12], encrypted)
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err := gcm3.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	_, err = NewAes(key, iv).GCM().NonceSize(16).TryTagSize(8)
+	assert.Error(t, err)
+}
+
+func TestGcmAuthFailed(t *testing.T) {
+	gcm1 := NewAes(key, iv).GCM().Additional([]byte("header"))
+	encrypted := mustEncrypt(t, gcm1, []byte("xq1_ddq"))
+
+	tampered := bytes.Clone(encrypted)
+	tampered[0] ^= 1
+	_, err := gcm1.Decrypt(tampered)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	gcm2 := NewAes(key, iv).GCM().Additional([]byte("other"))
+	_, err = gcm2.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+}
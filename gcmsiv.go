@@ -0,0 +1,145 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	gcmSivNonceSize = 12
+	gcmSivTagSize   = 16
+)
+
+// gcmSiv RFC 8452 AES-GCM-SIV, 每个 nonce 派生独立的认证和加密密钥,
+// nonce 重复只会暴露明文是否相同
+type gcmSiv struct {
+	block     cipher.Block
+	keySize   int
+	newCipher cipherFunc
+}
+
+func gcmSivFactory(keySize int, newCipher cipherFunc) aeadFactory {
+	return func(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+		if block.BlockSize() != polyvalBlockSize {
+			return nil, errors.New("gcm-siv: block size must be 16")
+		}
+
+		if keySize != 16 && keySize != 32 {
+			return nil, errors.New("gcm-siv: key size must be 16 or 32")
+		}
+
+		if nonceSize != gcmSivNonceSize {
+			return nil, errors.New("gcm-siv: nonce size must be 12")
+		}
+
+		if tagSize != gcmSivTagSize {
+			return nil, errors.New("gcm-siv: tag size must be 16")
+		}
+
+		return &gcmSiv{block: block, keySize: keySize, newCipher: newCipher}, nil
+	}
+}
+
+func (g *gcmSiv) NonceSize() int {
+	return gcmSivNonceSize
+}
+
+func (g *gcmSiv) Overhead() int {
+	return gcmSivTagSize
+}
+
+func (g *gcmSiv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSivNonceSize {
+		panic("gcm-siv: incorrect nonce length given to GCM-SIV")
+	}
+
+	authKey, block := g.deriveKeys(nonce)
+	tag := g.tag(authKey, block, nonce, plaintext, additionalData)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSivTagSize)
+	gcmSivCtr(block, tag, out, plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcmSiv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSivNonceSize {
+		panic("gcm-siv: incorrect nonce length given to GCM-SIV")
+	}
+
+	if len(ciphertext) < gcmSivTagSize {
+		return nil, ErrAuthFailed
+	}
+
+	size := len(ciphertext) - gcmSivTagSize
+	var tag [gcmSivTagSize]byte
+	copy(tag[:], ciphertext[size:])
+
+	authKey, block := g.deriveKeys(nonce)
+	ret, out := sliceForAppend(dst, size)
+	gcmSivCtr(block, tag, out, ciphertext[:size])
+
+	expected := g.tag(authKey, block, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	return ret, nil
+}
+
+// deriveKeys 取 AES(LE32(i) || nonce) 的前 8 字节拼接出认证密钥和加密密钥
+func (g *gcmSiv) deriveKeys(nonce []byte) (authKey []byte, block cipher.Block) {
+	var in, out [16]byte
+	copy(in[4:], nonce)
+
+	derived := make([]byte, 0, 16+g.keySize)
+	for i := 0; i < (16+g.keySize)/8; i++ {
+		binary.LittleEndian.PutUint32(in[:], uint32(i))
+		g.block.Encrypt(out[:], in[:])
+		derived = append(derived, out[:8]...)
+	}
+
+	block, err := g.newCipher(derived[16:])
+	if err != nil {
+		panic(err)
+	}
+
+	return derived[:16], block
+}
+
+func (g *gcmSiv) tag(authKey []byte, block cipher.Block, nonce, plaintext, additionalData []byte) (tag [gcmSivTagSize]byte) {
+	var lengths [polyvalBlockSize]byte
+	binary.LittleEndian.PutUint64(lengths[0:], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengths[:])
+
+	tag = p.sum()
+	subtle.XORBytes(tag[:], tag[:], nonce)
+	tag[15] &= 0x7f
+	block.Encrypt(tag[:], tag[:])
+	return
+}
+
+// gcmSivCtr 计数器为 tag 最高位置 1, 只递增前 32 位 (小端, 回绕)
+func gcmSivCtr(block cipher.Block, tag [gcmSivTagSize]byte, dst, src []byte) {
+	counter := tag
+	counter[15] |= 0x80
+
+	var stream [16]byte
+	for len(src) > 0 {
+		block.Encrypt(stream[:], counter[:])
+		n := subtle.XORBytes(dst, src, stream[:])
+		dst, src = dst[n:], src[n:]
+		binary.LittleEndian.PutUint32(counter[:], binary.LittleEndian.Uint32(counter[:])+1)
+	}
+}
@@ -0,0 +1,89 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolyval(t *testing.T) {
+	// RFC 8452 Appendix A
+	h, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	x, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")
+
+	p := newPolyval(h)
+	p.update(x)
+	sum := p.sum()
+	assert.Equal(t, "f7a3b47b846119fae5b7866cf5e5b77e", hex.EncodeToString(sum[:]))
+
+	// x^128 mod P 是 Montgomery 乘法的单位元
+	const oneLo, oneHi = 1, 1<<63 | 1<<62 | 1<<57
+	for _, v := range [][2]uint64{{0, 0}, {1, 0}, {^uint64(0), ^uint64(0)}, {0x0123456789abcdef, 0xfedcba9876543210}} {
+		rLo, rHi := polyvalDot(v[0], v[1], oneLo, oneHi)
+		assert.Equal(t, v, [2]uint64{rLo, rHi})
+	}
+}
+
+func BenchmarkPolyval(b *testing.B) {
+	p := newPolyval(key)
+	data := make([]byte, 1024)
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		p.update(data)
+	}
+}
+
+func TestGcmSiv(t *testing.T) {
+	gcmSiv1 := NewAes(key, iv).GCMSIV().Additional([]byte("header")).Base64()
+	testMethod(t, gcmSiv1, false, nil)
+
+	encrypted := mustEncrypt(t, gcmSiv1, []byte("xq1_ddq"))
+	encrypted[0] ^= 1
+	_, err := gcmSiv1.Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestGcmSivRfc8452(t *testing.T) {
+	nonce, _ := hex.DecodeString("030000000000000000000000")
+	key128, _ := hex.DecodeString("01000000000000000000000000000000")
+	key256, _ := hex.DecodeString("0100000000000000000000000000000000000000000000000000000000000000")
+
+	cases := []struct {
+		key        []byte
+		additional string
+		plainText  string
+		result     string
+	}{
+		{key128, "", "", "dc20e2d83f25705bb49e439eca56de25"},
+		{key128, "", "0100000000000000", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+		{key128, "", "010000000000000000000000", "7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639"},
+		{key128, "", "01000000000000000000000000000000", "743f7c8077ab25f8624e2e948579cf77303aaf90f6fe21199c6068577437a0c4"},
+		{key128, "01", "0200000000000000", "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508"},
+		{key256, "", "", "07f5f4169bbf55a8400cd47ea6fd400f"},
+		{key256, "", "0100000000000000", "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28"},
+	}
+
+	for _, c := range cases {
+		additional, _ := hex.DecodeString(c.additional)
+		plainText, _ := hex.DecodeString(c.plainText)
+
+		gcmSiv1 := NewAes(c.key, nonce).GCMSIV().Additional(additional).Hex()
+		encrypted := mustEncrypt(t, gcmSiv1, plainText)
+		assert.Equal(t, c.result, string(encrypted))
+
+		decrypted, err := gcmSiv1.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(plainText), hex.EncodeToString(decrypted))
+	}
+}
+
+func TestGcmSivNonceReuse(t *testing.T) {
+	gcmSiv1 := NewAes(key, iv).GCMSIV()
+
+	// nonce 重复时, 只有相同明文才得到相同密文
+	assert.Equal(t, mustEncrypt(t, gcmSiv1, []byte("xq1_ddq")), mustEncrypt(t, gcmSiv1, []byte("xq1_ddq")))
+	first, second := mustEncrypt(t, gcmSiv1, []byte("xq1_ddq")), mustEncrypt(t, gcmSiv1, []byte("xq1_ddr"))
+	assert.NotEqual(t, first[:6], second[:6])
+}
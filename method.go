@@ -1,6 +1,10 @@
 package encrypt
 
-import "crypto/cipher"
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+)
 
 type IMethod interface {
 	ECB() IEncrypt
@@ -8,10 +12,76 @@ type IMethod interface {
 	CTR() IEncrypt
 	OFB() IEncrypt
 	CFB() IEncrypt
+	GCM() IAead
+	CCM() IAead
+	SIV() IAead
+	GCMSIV() IAead
+	OCB() IAead
+	EAX() IAead
+	XTS() IXts
+	RandomIv() IMethod
+	Parallel(threshold int) IMethod
+	CBCCTS(variant CtsVariant) IEncrypt
+	CFB8() IEncrypt
+	CFBSegment(bits int) IEncrypt
+	ChunkedGCM(segmentSize int) IChunked
+
+	TryECB() (IEncrypt, error)
+	TryCBC() (IEncrypt, error)
+	TryCTR() (IEncrypt, error)
+	TryOFB() (IEncrypt, error)
+	TryCFB() (IEncrypt, error)
+	TryGCM() (IAead, error)
+	TryCCM() (IAead, error)
+	TrySIV() (IAead, error)
+	TryGCMSIV() (IAead, error)
+	TryOCB() (IAead, error)
+	TryEAX() (IAead, error)
+	TryXTS() (IXts, error)
+	TryCBCCTS(variant CtsVariant) (IEncrypt, error)
+	TryCFB8() (IEncrypt, error)
+	TryCFBSegment(bits int) (IEncrypt, error)
+	TryChunkedGCM(segmentSize int) (IChunked, error)
+}
+
+// KeyError key 长度错误, 可通过 errors.Is(err, ErrKeyLength) 判断
+type KeyError struct {
+	Algorithm string
+	Size      int
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s: %s: %d bytes", e.Algorithm, ErrKeyLength, e.Size)
+}
+
+func (e *KeyError) Is(target error) bool {
+	return target == ErrKeyLength
+}
+
+// IvError iv 长度错误, 可通过 errors.Is(err, ErrIvLength) 判断
+type IvError struct {
+	Algorithm string
+	Size      int
+	Expected  int
+}
+
+func (e *IvError) Error() string {
+	return fmt.Sprintf("%s: %s: %d bytes, expected %d", e.Algorithm, ErrIvLength, e.Size, e.Expected)
+}
+
+func (e *IvError) Is(target error) bool {
+	return target == ErrIvLength
 }
 
+type cipherFunc func(key []byte) (cipher.Block, error)
+
 type Method struct {
 	Base
+	name      string
+	key       []byte
+	newCipher cipherFunc
+	randomIv  bool
+	parallel  int
 }
 
 func NewMethod(block cipher.Block, iv []byte) *Method {
@@ -21,37 +91,366 @@ func NewMethod(block cipher.Block, iv []byte) *Method {
 	}}
 }
 
-func (m *Method) ECB() IEncrypt {
-	m.encryptor = newEcbEncryptor(m.block)
+func newNamedMethod(name string, block cipher.Block, iv []byte) *Method {
+	m := NewMethod(block, iv)
+	m.name = name
 	return m
 }
 
+// newCipherMethod 保留原始 key, 派生子密钥的模式 (GCM-SIV 等) 需要使用
+func newCipherMethod(name string, newCipher cipherFunc, key, iv []byte) (*Method, error) {
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, &KeyError{Algorithm: name, Size: len(key)}
+	}
+
+	m := newNamedMethod(name, block, iv)
+	m.key = key
+	m.newCipher = newCipher
+	return m, nil
+}
+
+// newSplitKeyMethod 双倍长度的 key 只用于 SIV 和 XTS, 创建时检查两半都是有效的 key,
+// 不创建 block, 其他模式返回 ErrNoKey
+func newSplitKeyMethod(name, mode string, newCipher cipherFunc, key, iv []byte) (*Method, error) {
+	m := newNamedMethod(name, nil, iv)
+	m.key = key
+	m.newCipher = newCipher
+	if _, _, err := m.splitKey(mode); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// must 不返回错误的版本出错时 panic
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// RandomIv 每次加密生成随机 iv (nonce) 并作为密文前缀, 解密时从前缀读取,
+// 此时创建时的 iv 可以为 nil
+func (m *Method) RandomIv() IMethod {
+	c := m.clone()
+	c.randomIv = true
+	return c
+}
+
+// Parallel ECB 和 CTR 在输入不小于 threshold 字节时分给多个协程处理,
+// 结果与单协程完全一致. threshold <= 0 时使用默认值 64KiB
+func (m *Method) Parallel(threshold int) IMethod {
+	if threshold <= 0 {
+		threshold = defaultParallelThreshold
+	}
+
+	c := m.clone()
+	c.parallel = threshold
+	return c
+}
+
+func (m *Method) ECB() IEncrypt {
+	return must(m.TryECB())
+}
+
 func (m *Method) CBC() IEncrypt {
-	m.checkIv()
-	m.encryptor = newCbcEncryptor(m.block, m.iv)
-	return m
+	return must(m.TryCBC())
 }
 
 func (m *Method) CTR() IEncrypt {
-	m.checkIv()
-	m.encryptor = newCtrEncryptor(m.block, m.iv)
-	return m
+	return must(m.TryCTR())
 }
 
 func (m *Method) OFB() IEncrypt {
-	m.checkIv()
-	m.encryptor = newOfbEncryptor(m.block, m.iv)
-	return m
+	return must(m.TryOFB())
 }
 
 func (m *Method) CFB() IEncrypt {
-	m.checkIv()
-	m.encryptor = newCfbEncryptor(m.block, m.iv)
-	return m
+	return must(m.TryCFB())
 }
 
-func (m *Method) checkIv() {
-	if m.iv == nil {
-		panic("iv is nil")
+func (m *Method) CFB8() IEncrypt {
+	return must(m.TryCFB8())
+}
+
+// CFBSegment 可选分段长度 (位) 的 CFB, CFB() 即 128 位分段
+func (m *Method) CFBSegment(bits int) IEncrypt {
+	return must(m.TryCFBSegment(bits))
+}
+
+func (m *Method) CBCCTS(variant CtsVariant) IEncrypt {
+	return must(m.TryCBCCTS(variant))
+}
+
+func (m *Method) GCM() IAead {
+	return must(m.TryGCM())
+}
+
+func (m *Method) CCM() IAead {
+	return must(m.TryCCM())
+}
+
+func (m *Method) OCB() IAead {
+	return must(m.TryOCB())
+}
+
+func (m *Method) EAX() IAead {
+	return must(m.TryEAX())
+}
+
+func (m *Method) SIV() IAead {
+	return must(m.TrySIV())
+}
+
+func (m *Method) GCMSIV() IAead {
+	return must(m.TryGCMSIV())
+}
+
+func (m *Method) XTS() IXts {
+	return must(m.TryXTS())
+}
+
+// ChunkedGCM 大文件使用的分段认证加密格式, 每个文件随机生成 salt 派生子密钥, 不使用创建时的 iv
+func (m *Method) ChunkedGCM(segmentSize int) IChunked {
+	return must(m.TryChunkedGCM(segmentSize))
+}
+
+func (m *Method) TryECB() (IEncrypt, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
 	}
+
+	c := m.clone()
+	c.encryptor = newEcbEncryptor(m.block, m.parallel)
+	return c, nil
+}
+
+func (m *Method) TryCBC() (IEncrypt, error) {
+	return m.blockMode("cbc", func(block cipher.Block, iv []byte) IEncryptor {
+		return newCbcEncryptor(block, iv)
+	})
+}
+
+func (m *Method) TryCTR() (IEncrypt, error) {
+	threshold := m.parallel
+	return m.blockMode("ctr", func(block cipher.Block, iv []byte) IEncryptor {
+		return newCtrEncryptor(block, iv, threshold)
+	})
+}
+
+func (m *Method) TryOFB() (IEncrypt, error) {
+	return m.blockMode("ofb", func(block cipher.Block, iv []byte) IEncryptor {
+		return newOfbEncryptor(block, iv)
+	})
+}
+
+func (m *Method) TryCFB() (IEncrypt, error) {
+	return m.blockMode("cfb", func(block cipher.Block, iv []byte) IEncryptor {
+		return newCfbEncryptor(block, iv)
+	})
+}
+
+func (m *Method) TryCFB8() (IEncrypt, error) {
+	return m.TryCFBSegment(8)
+}
+
+func (m *Method) TryCFBSegment(bits int) (IEncrypt, error) {
+	if m.block != nil && !validSegment(bits, m.block.BlockSize()) {
+		return nil, ErrSegmentSize
+	}
+
+	return m.blockMode("cfb", func(block cipher.Block, iv []byte) IEncryptor {
+		return newCfbSegmentEncryptor(block, iv, bits)
+	})
+}
+
+func (m *Method) TryCBCCTS(variant CtsVariant) (IEncrypt, error) {
+	if variant < Cs1 || variant > Cs3 {
+		return nil, ErrCtsVariant
+	}
+
+	return m.blockMode("cbc-cts", func(block cipher.Block, iv []byte) IEncryptor {
+		return newCtsEncryptor(block, iv, variant)
+	})
+}
+
+// TryGCM nonce 默认 12 字节, tag 默认 16 字节, 可通过 NonceSize 和 TagSize (12-16) 调整, 两者可以同时使用.
+// GCM, CCM, OCB, EAX 默认每次加密随机生成 nonce 并作为密文前缀, 固定 nonce 需要调用 FixedNonce
+func (m *Method) TryGCM() (IAead, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	return newAead(m.randomNonce(), "gcm", newGcm, gcmStandardNonceSize, gcmTagSize)
+}
+
+func (m *Method) TryCCM() (IAead, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	return newAead(m.randomNonce(), "ccm", newCcm, ccmDefaultNonceSize, ccmDefaultTagSize)
+}
+
+func (m *Method) TryOCB() (IAead, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	return newAead(m.randomNonce(), "ocb", newOcb, ocbDefaultNonceSize, ocbDefaultTagSize)
+}
+
+func (m *Method) TryEAX() (IAead, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	return newAead(m.randomNonce(), "eax", newEax, eaxDefaultNonceSize, eaxDefaultTagSize)
+}
+
+// TrySIV 两半 key 分别用于 S2V 和 CTR, 如 32 字节的 AES key 对应 AES-SIV-256,
+// 更长的 key 使用 TryNewAesSiv
+func (m *Method) TrySIV() (IAead, error) {
+	macBlock, ctrBlock, err := m.splitKey("siv")
+	if err != nil {
+		return nil, err
+	}
+
+	return newAead(m, "siv", sivFactory(macBlock, ctrBlock), 0, sivTagSize)
+}
+
+func (m *Method) TryGCMSIV() (IAead, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	if m.newCipher == nil {
+		return nil, ErrNoKey
+	}
+
+	return newAead(m, "gcm-siv", gcmSivFactory(len(m.key), m.newCipher), gcmSivNonceSize, gcmSivTagSize)
+}
+
+func (m *Method) TryXTS() (IXts, error) {
+	dataBlock, tweakBlock, err := m.splitKey("xts")
+	if err != nil {
+		return nil, err
+	}
+
+	// IEEE 1619 要求两半 key 不同
+	half := len(m.key) / 2
+	if subtle.ConstantTimeCompare(m.key[:half], m.key[half:]) == 1 {
+		return nil, ErrXtsDuplicateKey
+	}
+
+	return newXts(dataBlock, tweakBlock)
+}
+
+func (m *Method) TryChunkedGCM(segmentSize int) (IChunked, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	// 每个文件由 key 派生子密钥, 需要原始 key
+	if m.newCipher == nil {
+		return nil, ErrNoKey
+	}
+
+	chunked, err := newChunked(m.key, m.newCipher, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunked, nil
+}
+
+// randomNonce nonce 重复会破坏安全性的模式默认使用随机 nonce
+func (m *Method) randomNonce() *Method {
+	c := m.clone()
+	c.randomIv = true
+	return c
+}
+
+// clone 每一步都返回新的值, 同一个 Method 可以在多个协程中派生不同的配置
+func (m *Method) clone() *Method {
+	c := *m
+	return &c
+}
+
+// blockMode 需要一个分组长度 iv 的模式
+func (m *Method) blockMode(mode string, build func(block cipher.Block, iv []byte) IEncryptor) (IEncrypt, error) {
+	if err := m.checkBlock(); err != nil {
+		return nil, err
+	}
+
+	block := m.block
+	if err := m.checkIv(mode, block.BlockSize()); err != nil {
+		return nil, err
+	}
+
+	c := m.clone()
+	c.encryptor = m.withIv(block.BlockSize(), func(iv []byte) IEncryptor {
+		return build(block, iv)
+	})
+	return c, nil
+}
+
+// splitKey 将双倍长度的 key 拆分为两个 block, 如 32 字节的 key 拆为两个 AES-128
+func (m *Method) splitKey(mode string) (first, second cipher.Block, err error) {
+	if m.newCipher == nil {
+		return nil, nil, ErrNoKey
+	}
+
+	half := len(m.key) / 2
+	if len(m.key)%2 != 0 {
+		return nil, nil, &KeyError{Algorithm: m.label(mode), Size: len(m.key)}
+	}
+
+	if first, err = m.newCipher(m.key[:half]); err != nil {
+		return nil, nil, &KeyError{Algorithm: m.label(mode), Size: len(m.key)}
+	}
+
+	if second, err = m.newCipher(m.key[half:]); err != nil {
+		return nil, nil, &KeyError{Algorithm: m.label(mode), Size: len(m.key)}
+	}
+
+	return
+}
+
+func (m *Method) checkBlock() error {
+	if m.block == nil {
+		return ErrNoKey
+	}
+
+	return nil
+}
+
+// checkIv 随机 iv 时不需要创建时的 iv
+func (m *Method) checkIv(mode string, size int) error {
+	if m.randomIv || len(m.iv) == size {
+		return nil
+	}
+
+	return &IvError{Algorithm: m.label(mode), Size: len(m.iv), Expected: size}
+}
+
+// label 错误信息中的算法名, 如 aes-cbc
+func (m *Method) label(mode string) string {
+	if m.name == "" {
+		return mode
+	}
+
+	return m.name + "-" + mode
+}
+
+// withIv 固定 iv 时直接创建加密器, 随机 iv 时每次加密重新创建
+func (m *Method) withIv(size int, build func(iv []byte) IEncryptor) IEncryptor {
+	if m.randomIv {
+		return newRandomIvEncryptor(size, build)
+	}
+
+	return build(m.iv)
 }
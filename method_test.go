@@ -0,0 +1,111 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryNewKeyLength(t *testing.T) {
+	_, err := TryNewAes(make([]byte, 7), iv)
+	assert.ErrorIs(t, err, ErrKeyLength)
+
+	var keyErr *KeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, &KeyError{Algorithm: "aes", Size: 7}, keyErr)
+	assert.EqualError(t, err, "aes: key length invalid: 7 bytes")
+
+	_, err = TryNewDes(key, iv)
+	assert.Equal(t, &KeyError{Algorithm: "des", Size: 16}, err)
+
+	_, err = TryNewTripleDes(key[:10], iv)
+	assert.Equal(t, &KeyError{Algorithm: "3des", Size: 10}, err)
+
+	_, err = TryNewChaCha20Poly1305(key, make([]byte, 12))
+	assert.Equal(t, &KeyError{Algorithm: "chacha20poly1305", Size: 16}, err)
+
+	_, err = TryNewXts(make([]byte, 20))
+	assert.ErrorIs(t, err, ErrKeyLength)
+
+	// 双倍长度的 key 创建时就返回错误, 只能通过 SIV/XTS 的入口使用
+	for _, size := range []int{48, 64} {
+		_, err = TryNewAes(make([]byte, size), iv)
+		assert.Equal(t, &KeyError{Algorithm: "aes", Size: size}, err)
+
+		_, err = TryNewAesSiv(make([]byte, size), nil)
+		assert.NoError(t, err)
+	}
+
+	_, err = TryNewAesSiv(make([]byte, 40), nil)
+	assert.Equal(t, &KeyError{Algorithm: "aes-siv", Size: 40}, err)
+
+	// 后半个 key 无效时创建即失败
+	halfCipher := func(key []byte) (cipher.Block, error) {
+		if len(key) != 16 || key[0] == 0xff {
+			return nil, ErrKeyLength
+		}
+
+		return aes.NewCipher(key)
+	}
+
+	doubleKey := append(make([]byte, 16), bytes.Repeat([]byte{0xff}, 16)...)
+	_, err = newSplitKeyMethod("test", "siv", halfCipher, doubleKey, nil)
+	assert.Equal(t, &KeyError{Algorithm: "test-siv", Size: 32}, err)
+
+	assert.PanicsWithError(t, "aes: key length invalid: 7 bytes", func() {
+		NewAes(make([]byte, 7), iv)
+	})
+}
+
+func TestTryIvLength(t *testing.T) {
+	_, err := NewAes(key, nil).TryCBC()
+	assert.ErrorIs(t, err, ErrIvLength)
+	assert.Equal(t, &IvError{Algorithm: "aes-cbc", Size: 0, Expected: 16}, err)
+
+	_, err = NewAes(key, iv[:8]).TryCTR()
+	assert.Equal(t, &IvError{Algorithm: "aes-ctr", Size: 8, Expected: 16}, err)
+	assert.EqualError(t, err, "aes-ctr: iv length invalid: 8 bytes, expected 16")
+
+	_, err = NewDes(key[:8], iv).TryOFB()
+	assert.Equal(t, &IvError{Algorithm: "des-ofb", Size: 16, Expected: 8}, err)
+
+	_, err = NewAes(key, iv[:8]).GCM().TryFixedNonce()
+	assert.Equal(t, &IvError{Algorithm: "aes-gcm", Size: 8, Expected: 12}, err)
+
+	_, err = TryNewXChaCha20Poly1305(make([]byte, 32), make([]byte, 12))
+	assert.Equal(t, &IvError{Algorithm: "xchacha20poly1305", Size: 12, Expected: 24}, err)
+
+	// 随机 iv 不需要创建时的 iv
+	_, err = NewAes(key, nil).RandomIv().TryCBC()
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() { NewAes(key, nil).CBC() })
+}
+
+func TestTryModeOptions(t *testing.T) {
+	_, err := NewAes(key, iv).TryCFBSegment(12)
+	assert.ErrorIs(t, err, ErrSegmentSize)
+
+	_, err = NewAes(key, iv).TryCBCCTS(CtsVariant(5))
+	assert.ErrorIs(t, err, ErrCtsVariant)
+
+	_, err = NewMethod(nil, iv).TryECB()
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, err = NewMethod(nil, iv).TrySIV()
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	// 出错时保留原来的设置
+	gcm1 := NewAes(key, iv).GCM()
+	_, err = gcm1.TryTagSize(3)
+	assert.Error(t, err)
+	testMethod(t, gcm1, false, nil)
+
+	gcm2, err := gcm1.TryNonceSize(16)
+	assert.NoError(t, err)
+	testMethod(t, gcm2, false, nil)
+}
@@ -6,25 +6,111 @@ import (
 )
 
 func NewAes(key, iv []byte) IMethod {
-	block, err := aes.NewCipher(key)
+	return must(TryNewAes(key, iv))
+}
+
+func NewDes(key, iv []byte) IMethod {
+	return must(TryNewDes(key, iv))
+}
+
+func NewTripleDes(key, iv []byte) IMethod {
+	return must(TryNewTripleDes(key, iv))
+}
+
+// NewBlowfish key 为 1 到 56 字节, 分组与 iv 为 8 字节
+func NewBlowfish(key, iv []byte) IMethod {
+	return must(TryNewBlowfish(key, iv))
+}
+
+// NewTwofish key 为 16, 24 或 32 字节
+func NewTwofish(key, iv []byte) IMethod {
+	return must(TryNewTwofish(key, iv))
+}
+
+// NewCamellia key 为 16, 24 或 32 字节
+func NewCamellia(key, iv []byte) IMethod {
+	return must(TryNewCamellia(key, iv))
+}
+
+// NewAria key 为 16, 24 或 32 字节
+func NewAria(key, iv []byte) IMethod {
+	return must(TryNewAria(key, iv))
+}
+
+// NewSm4 国密 SM4, key 与 iv 均为 16 字节
+func NewSm4(key, iv []byte) IMethod {
+	return must(TryNewSm4(key, iv))
+}
+
+// TryNewAes key 长度错误时返回 *KeyError
+func TryNewAes(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("aes", aes.NewCipher, key, iv)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return NewMethod(block, iv)
+
+	return m, nil
 }
 
-func NewDes(key, iv []byte) IMethod {
+func TryNewDes(key, iv []byte) (IMethod, error) {
 	block, err := des.NewCipher(key)
 	if err != nil {
-		panic(err)
+		return nil, &KeyError{Algorithm: "des", Size: len(key)}
 	}
-	return NewMethod(block, iv)
+
+	return newNamedMethod("des", block, iv), nil
 }
 
-func NewTripleDes(key, iv []byte) IMethod {
+func TryNewTripleDes(key, iv []byte) (IMethod, error) {
 	block, err := des.NewTripleDESCipher(key)
 	if err != nil {
-		panic(err)
+		return nil, &KeyError{Algorithm: "3des", Size: len(key)}
+	}
+
+	return newNamedMethod("3des", block, iv), nil
+}
+
+func TryNewSm4(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("sm4", NewSm4Cipher, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func TryNewBlowfish(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("blowfish", NewBlowfishCipher, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func TryNewTwofish(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("twofish", NewTwofishCipher, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func TryNewCamellia(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("camellia", NewCamelliaCipher, key, iv)
+	if err != nil {
+		return nil, err
 	}
-	return NewMethod(block, iv)
+
+	return m, nil
+}
+
+func TryNewAria(key, iv []byte) (IMethod, error) {
+	m, err := newCipherMethod("aria", NewAriaCipher, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
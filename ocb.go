@@ -0,0 +1,186 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math/bits"
+)
+
+const (
+	ocbBlockSize        = 16
+	ocbDefaultNonceSize = 12
+	ocbDefaultTagSize   = 16
+	ocbMaxNonceSize     = 15
+)
+
+// ocb RFC 7253 OCB3
+type ocb struct {
+	block     cipher.Block
+	nonceSize int
+	tagSize   int
+	lStar     [ocbBlockSize]byte
+	lDollar   [ocbBlockSize]byte
+	l         [64][ocbBlockSize]byte
+}
+
+func newOcb(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != ocbBlockSize {
+		return nil, errors.New("ocb: block size must be 16")
+	}
+
+	if nonceSize < 1 || nonceSize > ocbMaxNonceSize {
+		return nil, errors.New("ocb: nonce size must be between 1 and 15")
+	}
+
+	if tagSize < 1 || tagSize > ocbBlockSize {
+		return nil, errors.New("ocb: tag size must be between 1 and 16")
+	}
+
+	o := &ocb{block: block, nonceSize: nonceSize, tagSize: tagSize}
+	block.Encrypt(o.lStar[:], o.lStar[:])
+	o.lDollar = cmacDouble(o.lStar)
+	o.l[0] = cmacDouble(o.lDollar)
+	for i := 1; i < len(o.l); i++ {
+		o.l[i] = cmacDouble(o.l[i-1])
+	}
+
+	return o, nil
+}
+
+func (o *ocb) NonceSize() int {
+	return o.nonceSize
+}
+
+func (o *ocb) Overhead() int {
+	return o.tagSize
+}
+
+func (o *ocb) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("ocb: incorrect nonce length given to OCB")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+o.tagSize)
+	tag := o.crypt(true, out, plaintext, nonce, additionalData)
+	copy(out[len(plaintext):], tag[:o.tagSize])
+	return ret
+}
+
+func (o *ocb) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("ocb: incorrect nonce length given to OCB")
+	}
+
+	if len(ciphertext) < o.tagSize {
+		return nil, ErrAuthFailed
+	}
+
+	size := len(ciphertext) - o.tagSize
+	tag := ciphertext[size:]
+	ret, out := sliceForAppend(dst, size)
+
+	expected := o.crypt(false, out, ciphertext[:size], nonce, additionalData)
+	if subtle.ConstantTimeCompare(expected[:o.tagSize], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	return ret, nil
+}
+
+// initialOffset 由 nonce 计算 Offset_0
+func (o *ocb) initialOffset(nonce []byte) (offset [ocbBlockSize]byte) {
+	var n [ocbBlockSize]byte
+	n[0] = byte(o.tagSize*8%128) << 1
+	n[ocbBlockSize-1-len(nonce)] |= 1
+	copy(n[ocbBlockSize-len(nonce):], nonce)
+
+	bottom := int(n[ocbBlockSize-1] & 0x3f)
+	n[ocbBlockSize-1] &= 0xc0
+
+	var stretch [ocbBlockSize + 8]byte
+	o.block.Encrypt(stretch[:], n[:])
+	subtle.XORBytes(stretch[ocbBlockSize:], stretch[:8], stretch[1:9])
+
+	shift, bit := bottom/8, uint(bottom%8)
+	for i := range offset {
+		offset[i] = stretch[i+shift]<<bit | stretch[i+shift+1]>>(8-bit)
+	}
+
+	return
+}
+
+func (o *ocb) crypt(encrypt bool, dst, src, nonce, additionalData []byte) (tag [ocbBlockSize]byte) {
+	offset := o.initialOffset(nonce)
+
+	var checksum, tmp [ocbBlockSize]byte
+	for i := 1; len(src) >= ocbBlockSize; i++ {
+		subtle.XORBytes(offset[:], offset[:], o.l[bits.TrailingZeros(uint(i))][:])
+		subtle.XORBytes(tmp[:], src[:ocbBlockSize], offset[:])
+		if encrypt {
+			subtle.XORBytes(checksum[:], checksum[:], src[:ocbBlockSize])
+			o.block.Encrypt(tmp[:], tmp[:])
+		} else {
+			o.block.Decrypt(tmp[:], tmp[:])
+		}
+
+		subtle.XORBytes(dst, tmp[:], offset[:])
+		if !encrypt {
+			subtle.XORBytes(checksum[:], checksum[:], dst[:ocbBlockSize])
+		}
+
+		dst, src = dst[ocbBlockSize:], src[ocbBlockSize:]
+	}
+
+	if len(src) > 0 {
+		var pad [ocbBlockSize]byte
+		subtle.XORBytes(offset[:], offset[:], o.lStar[:])
+		o.block.Encrypt(pad[:], offset[:])
+		subtle.XORBytes(dst, src, pad[:])
+
+		plain := src
+		if !encrypt {
+			plain = dst[:len(src)]
+		}
+
+		var last [ocbBlockSize]byte
+		copy(last[:], plain)
+		last[len(plain)] = 0x80
+		subtle.XORBytes(checksum[:], checksum[:], last[:])
+	}
+
+	subtle.XORBytes(tag[:], checksum[:], offset[:])
+	subtle.XORBytes(tag[:], tag[:], o.lDollar[:])
+	o.block.Encrypt(tag[:], tag[:])
+
+	hash := o.hash(additionalData)
+	subtle.XORBytes(tag[:], tag[:], hash[:])
+	return
+}
+
+func (o *ocb) hash(additionalData []byte) (sum [ocbBlockSize]byte) {
+	var offset, tmp [ocbBlockSize]byte
+	for i := 1; len(additionalData) >= ocbBlockSize; i++ {
+		subtle.XORBytes(offset[:], offset[:], o.l[bits.TrailingZeros(uint(i))][:])
+		subtle.XORBytes(tmp[:], additionalData[:ocbBlockSize], offset[:])
+		o.block.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+		additionalData = additionalData[ocbBlockSize:]
+	}
+
+	if len(additionalData) > 0 {
+		var last [ocbBlockSize]byte
+		copy(last[:], additionalData)
+		last[len(additionalData)] = 0x80
+		subtle.XORBytes(offset[:], offset[:], o.lStar[:])
+		subtle.XORBytes(tmp[:], last[:], offset[:])
+		o.block.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+	}
+
+	return
+}
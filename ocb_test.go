@@ -0,0 +1,81 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOcb(t *testing.T) {
+	ocb1 := NewAes(key, iv).OCB().Additional([]byte("header")).Base64()
+	testMethod(t, ocb1, false, nil)
+
+	ocb2 := NewAes(key, iv).OCB().NonceSize(15).TagSize(8).Hex()
+	testMethod(t, ocb2, false, nil)
+}
+
+func TestOcbRfc7253(t *testing.T) {
+	const (
+		rfcKey = "000102030405060708090a0b0c0d0e0f"
+		data8  = "0001020304050607"
+		data16 = data8 + "08090a0b0c0d0e0f"
+		data24 = data16 + "1011121314151617"
+		data32 = data24 + "18191a1b1c1d1e1f"
+		data39 = data32 + "20212223242526"
+	)
+
+	cases := []struct {
+		key     string
+		nonce   string
+		header  string
+		text    string
+		tagSize int
+		result  string
+	}{
+		// RFC 7253 附录 A
+		{rfcKey, "bbaa99887766554433221100", "", "", 16, "785407bfffc8ad9edcc5520ac9111ee6"},
+		{rfcKey, "bbaa99887766554433221101", data8, data8, 16, "6820b3657b6f615a5725bda0d3b4eb3a257c9af1f8f03009"},
+		{rfcKey, "bbaa99887766554433221102", data8, "", 16, "81017f8203f081277152fade694a0a00"},
+		{rfcKey, "bbaa99887766554433221103", "", data8, 16, "45dd69f8f5aae72414054cd1f35d82760b2cd00d2f99bfa9"},
+		{rfcKey, "bbaa99887766554433221104", data16, data16, 16, "571d535b60b277188be5147170a9a22c3ad7a4ff3835b8c5701c1ccec8fc3358"},
+		{rfcKey, "bbaa99887766554433221107", data24, data24, 16, "1ca2207308c87c010756104d8840ce1952f09673a448a122c92c62241051f57356d7f3c90bb0e07f"},
+		{rfcKey, "bbaa9988776655443322110a", data32, data32, 16, "bd6f6c496201c69296c11efd138a467abd3c707924b964deaffc40319af5a48540fbba186c5553c68ad9f592a79a4240"},
+		{rfcKey, "bbaa9988776655443322110d", data39, data39, 16, "d5ca91748410c1751ff8a2f618255b68a0a12e093ff454606e59f9c1d0ddc54b65e8628e568bad2fdfe3b6c88d1ae1cc6fd3d1df0351ac"},
+		// 96 位 tag, nonce 编码中包含 tag 长度, 密文也会不同
+		{"0f0e0d0c0b0a09080706050403020100", "bbaa9988776655443322110d", data39, data39, 12, "1792a4e31e0755fb03e31b22116e6c2ddf9efd6e33d536f1a0124b0a55bae884ed93481529c76b7b6133b76795b5212d285ea4"},
+		// 不足一个分组和跨分组的长度, 与 OpenSSL 的 AES-128-OCB 结果一致
+		{rfcKey, "bbaa99887766554433221120", data8[:14], data8[:14], 16, "6a73b16f3ebea591655c91a53cf05b0448c30b1034d9bd"},
+		{rfcKey, "bbaa99887766554433221121", data24[:46], data24[:46], 16, "1092acbe1e0f39dc4431414ee65906883b75ac38239207cd8206abab77704659d89fbcd65aa40e"},
+		{rfcKey, "bbaa99887766554433221122", "", data24[:46], 12, "78240ffd0f849ad810e39b45f28149224219837de8176a300d0926cca895758301102f"},
+	}
+
+	for _, c := range cases {
+		ocbKey, _ := hex.DecodeString(c.key)
+		nonce, _ := hex.DecodeString(c.nonce)
+		header, _ := hex.DecodeString(c.header)
+		text, _ := hex.DecodeString(c.text)
+
+		ocb1 := NewAes(ocbKey, nonce).OCB().TagSize(c.tagSize).FixedNonce().Additional(header).Hex()
+		encrypted := mustEncrypt(t, ocb1, text)
+		assert.Equal(t, c.result, string(encrypted), c.nonce)
+
+		decrypted, err := ocb1.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, c.text, hex.EncodeToString(decrypted))
+	}
+}
+
+func TestOcbAuthFailed(t *testing.T) {
+	ocb1 := NewAes(key, iv).OCB().Additional([]byte("header"))
+	encrypted := mustEncrypt(t, ocb1, []byte("1234567890abcdefghijklmnopqrstuvw"))
+	encrypted[20] ^= 1
+
+	_, err := ocb1.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	var authErr *AuthError
+	assert.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "ocb", authErr.Mode)
+}
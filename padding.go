@@ -1,26 +1,41 @@
 package encrypt
 
 import (
-	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
 )
 
 type IPadding interface {
 	Fill(src []byte, blockSize int) []byte
-	Restore(src []byte, blockSize int) []byte
+	Restore(src []byte, blockSize int) ([]byte, error)
 }
 
 type Padding struct {
 }
 
+// NoPadding 不做任何填充, 分组模式下输入必须按分组对齐
 type NoPadding struct {
 }
 
 func (n NoPadding) Fill(src []byte, blockSize int) []byte {
-	times := blockSize - len(src)%blockSize
-	return append(src, bytes.Repeat([]byte{byte(0)}, times)...)
+	return src
 }
 
-func (n NoPadding) Restore(src []byte, blockSize int) []byte {
+func (n NoPadding) Restore(src []byte, blockSize int) ([]byte, error) {
+	return src, nil
+}
+
+// LegacyNoPadding 实际是补零, 会去掉明文末尾的 0x00, 仅用于兼容旧数据
+type LegacyNoPadding struct {
+}
+
+func (n LegacyNoPadding) Fill(src []byte, blockSize int) []byte {
+	padText, _ := appendPadding(src, blockSize, 0)
+	return padText
+}
+
+func (n LegacyNoPadding) Restore(src []byte, blockSize int) ([]byte, error) {
 	size, zero := len(src)-1, byte(0)
 	for ; size > 0; size-- {
 		if src[size] != zero {
@@ -28,27 +43,25 @@ func (n NoPadding) Restore(src []byte, blockSize int) []byte {
 		}
 	}
 
-	return src[:size+1]
+	return src[:size+1], nil
 }
 
 type ZeroPadding struct {
 }
 
 func (z ZeroPadding) Fill(src []byte, blockSize int) []byte {
-	padding := blockSize - len(src)%blockSize
-	padText := make([]byte, len(src)+padding)
-	copy(padText, src)
+	padText, _ := appendPadding(src, blockSize, 0)
 	return padText
 }
 
-func (z ZeroPadding) Restore(src []byte, blockSize int) []byte {
+func (z ZeroPadding) Restore(src []byte, blockSize int) ([]byte, error) {
 	// Find the last non-zero byte
 	for i := len(src) - 1; i >= 0; i-- {
 		if src[i] != 0 {
-			return src[:i+1]
+			return src[:i+1], nil
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 type Pkcs7Padding struct {
@@ -56,16 +69,135 @@ type Pkcs7Padding struct {
 
 func (p Pkcs7Padding) Fill(src []byte, blockSize int) []byte {
 	padding := blockSize - len(src)%blockSize
-	padText := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(src, padText...)
+	padText, _ := appendPadding(src, blockSize, byte(padding))
+	return padText
 }
 
-func (p Pkcs7Padding) Restore(src []byte, blockSize int) []byte {
+// Restore 校验填充的耗时与填充内容无关, 避免成为 padding oracle
+func (p Pkcs7Padding) Restore(src []byte, blockSize int) ([]byte, error) {
 	length := len(src)
-	maxIndex := length - int(src[length-1])
-	return src[:maxIndex]
+	if length == 0 || length%blockSize != 0 || length < blockSize {
+		return nil, ErrPaddingSize
+	}
+
+	padding := int(src[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, padding) & subtle.ConstantTimeLessOrEq(padding, blockSize)
+
+	// 检查最后一个分组的所有字节, 位于填充范围内的必须等于填充长度
+	for i := 1; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padding)
+		same := subtle.ConstantTimeByteEq(src[length-i], byte(padding))
+		good &= same | (inPadding ^ 1)
+	}
+
+	if good != 1 {
+		return nil, ErrPaddingSize
+	}
+
+	return src[:length-padding], nil
 }
 
 type Pkcs5Padding struct {
 	Pkcs7Padding
 }
+
+// AnsiX923Padding ANSI X9.23, 补零, 最后一个字节为填充长度
+type AnsiX923Padding struct {
+}
+
+func (x AnsiX923Padding) Fill(src []byte, blockSize int) []byte {
+	padText, tail := appendPadding(src, blockSize, 0)
+	tail[len(tail)-1] = byte(len(tail))
+	return padText
+}
+
+func (x AnsiX923Padding) Restore(src []byte, blockSize int) ([]byte, error) {
+	size, err := paddingLength(src, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range src[len(src)-size : len(src)-1] {
+		if b != 0 {
+			return nil, ErrPaddingSize
+		}
+	}
+
+	return src[:len(src)-size], nil
+}
+
+// Iso10126Padding ISO 10126, 随机字节填充, 最后一个字节为填充长度
+type Iso10126Padding struct {
+}
+
+func (i Iso10126Padding) Fill(src []byte, blockSize int) []byte {
+	padText, tail := appendPadding(src, blockSize, 0)
+	if _, err := io.ReadFull(rand.Reader, tail[:len(tail)-1]); err != nil {
+		panic(err)
+	}
+
+	tail[len(tail)-1] = byte(len(tail))
+	return padText
+}
+
+func (i Iso10126Padding) Restore(src []byte, blockSize int) ([]byte, error) {
+	size, err := paddingLength(src, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return src[:len(src)-size], nil
+}
+
+// Iso7816Padding ISO/IEC 7816-4, 先补 0x80 再补零
+type Iso7816Padding struct {
+}
+
+func (i Iso7816Padding) Fill(src []byte, blockSize int) []byte {
+	padText, tail := appendPadding(src, blockSize, 0)
+	tail[0] = 0x80
+	return padText
+}
+
+func (i Iso7816Padding) Restore(src []byte, blockSize int) ([]byte, error) {
+	if len(src) == 0 || len(src)%blockSize != 0 {
+		return nil, ErrPaddingSize
+	}
+
+	for i := len(src) - 1; i >= len(src)-blockSize; i-- {
+		if src[i] == 0x80 {
+			return src[:i], nil
+		}
+
+		if src[i] != 0 {
+			break
+		}
+	}
+
+	return nil, ErrPaddingSize
+}
+
+// appendPadding 在 src 后追加到分组整数倍, 填充部分全部为 value.
+// src 容量足够时直接在原数组上追加
+func appendPadding(src []byte, blockSize int, value byte) (padText, tail []byte) {
+	padText, tail = sliceForAppend(src, blockSize-len(src)%blockSize)
+	for i := range tail {
+		tail[i] = value
+	}
+
+	return
+}
+
+// paddingLength 读取最后一个字节表示的填充长度并校验范围
+func paddingLength(src []byte, blockSize int) (int, error) {
+	if len(src) == 0 || len(src)%blockSize != 0 {
+		return 0, ErrPaddingSize
+	}
+
+	size := int(src[len(src)-1])
+	if size == 0 || size > blockSize {
+		return 0, ErrPaddingSize
+	}
+
+	return size, nil
+}
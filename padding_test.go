@@ -0,0 +1,143 @@
+package encrypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoPadding(t *testing.T) {
+	text := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 0, 0, 0}
+
+	cbc1 := NewAes(key, iv).CBC().NoPadding()
+	encrypted := mustEncrypt(t, cbc1, text)
+	assert.Len(t, encrypted, len(text))
+
+	decrypted, err := cbc1.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+
+	_, err = NewAes(key, nil).ECB().NoPadding().Encrypt(text[:13])
+	assert.ErrorIs(t, err, ErrNotFullBlocks)
+
+	_, err = cbc1.Decrypt(encrypted[:13])
+	assert.ErrorIs(t, err, ErrNotFullBlocks)
+
+	// 流模式不要求对齐, 长度保持不变
+	ctr1 := NewAes(key, iv).CTR().NoPadding()
+	encrypted = mustEncrypt(t, ctr1, text[:13])
+	assert.Len(t, encrypted, 13)
+}
+
+func TestLegacyNoPadding(t *testing.T) {
+	text := []byte{1, 2, 3, 0}
+
+	ecb1 := NewAes(key, nil).ECB().LegacyNoPadding()
+	encrypted := mustEncrypt(t, ecb1, text)
+	assert.Len(t, encrypted, 16)
+
+	decrypted, err := ecb1.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, text[:3], decrypted)
+}
+
+func TestPaddings(t *testing.T) {
+	encryptors := map[string]IEncrypt{
+		"x923":     NewAes(key, iv).CBC().AnsiX923Padding().Base64(),
+		"iso10126": NewAes(key, iv).CBC().Iso10126Padding().Base64(),
+		"iso7816":  NewAes(key, iv).CBC().Iso7816Padding().Base64(),
+	}
+
+	for name, encryptor := range encryptors {
+		t.Run(name, func(t *testing.T) {
+			testMethod(t, encryptor, false, nil)
+		})
+	}
+}
+
+func TestPaddingFill(t *testing.T) {
+	text := []byte{0xaa, 0xbb, 0xcc}
+
+	assert.Equal(t, []byte{0xaa, 0xbb, 0xcc, 0, 0, 0, 0, 5}, AnsiX923Padding{}.Fill(text, 8))
+	assert.Equal(t, []byte{0xaa, 0xbb, 0xcc, 0x80, 0, 0, 0, 0}, Iso7816Padding{}.Fill(text, 8))
+
+	filled := Iso10126Padding{}.Fill(text, 8)
+	assert.Len(t, filled, 8)
+	assert.Equal(t, byte(5), filled[7])
+
+	// 已对齐时补一个完整分组
+	assert.Len(t, AnsiX923Padding{}.Fill(make([]byte, 8), 8), 16)
+	assert.Len(t, Iso7816Padding{}.Fill(make([]byte, 8), 8), 16)
+}
+
+func TestPaddingRestoreInvalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		padding IPadding
+		src     []byte
+	}{
+		{"x923 nonzero filler", AnsiX923Padding{}, []byte{1, 2, 3, 0, 1, 0, 0, 5}},
+		{"x923 zero length", AnsiX923Padding{}, []byte{1, 2, 3, 4, 5, 6, 7, 0}},
+		{"x923 too long", AnsiX923Padding{}, []byte{1, 2, 3, 4, 5, 6, 7, 9}},
+		{"iso10126 too long", Iso10126Padding{}, []byte{1, 2, 3, 4, 5, 6, 7, 9}},
+		{"iso10126 empty", Iso10126Padding{}, []byte{}},
+		{"iso7816 no marker", Iso7816Padding{}, []byte{1, 2, 3, 4, 5, 0, 0, 0}},
+		{"iso7816 all zero", Iso7816Padding{}, make([]byte, 8)},
+		{"iso7816 unaligned", Iso7816Padding{}, []byte{1, 0x80, 0}},
+	}
+
+	for _, c := range cases {
+		_, err := c.padding.Restore(c.src, 8)
+		assert.ErrorIs(t, err, ErrPaddingSize, c.name)
+	}
+
+	restored, err := Iso7816Padding{}.Restore([]byte{1, 2, 3, 4, 5, 6, 7, 0x80}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7}, restored)
+}
+
+func TestPaddingDecryptError(t *testing.T) {
+	// 用无填充解密会得到错误的填充
+	encrypted := mustEncrypt(t, NewAes(key, iv).CBC().NoPadding(), make([]byte, 16))
+	_, err := NewAes(key, iv).CBC().AnsiX923Padding().Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrPaddingSize)
+}
+
+func TestPkcs7Restore(t *testing.T) {
+	restored, err := Pkcs7Padding{}.Restore([]byte{1, 2, 3, 4, 5, 3, 3, 3}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5}, restored)
+
+	restored, err = Pkcs7Padding{}.Restore(bytes.Repeat([]byte{8}, 8), 8)
+	assert.NoError(t, err)
+	assert.Empty(t, restored)
+
+	cases := map[string][]byte{
+		"empty":      {},
+		"unaligned":  {1, 2, 3},
+		"zero":       {1, 2, 3, 4, 5, 6, 7, 0},
+		"too long":   {1, 2, 3, 4, 5, 6, 7, 9},
+		"mismatched": {1, 2, 3, 4, 5, 3, 2, 3},
+		"max":        {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+
+	for name, src := range cases {
+		assert.NotPanics(t, func() {
+			_, err := Pkcs7Padding{}.Restore(src, 8)
+			assert.ErrorIs(t, err, ErrPaddingSize, name)
+		})
+	}
+}
+
+func TestPkcs7DecryptError(t *testing.T) {
+	encrypted := mustEncrypt(t, NewAes(key, iv).CBC().Pkcs7Padding(), []byte("hello world"))
+
+	// 篡改最后一个字节使填充无效
+	encrypted[len(encrypted)-1] ^= 0x01
+	_, err := NewAes(key, iv).CBC().Pkcs7Padding().Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrPaddingSize)
+
+	_, err = NewAes(key, iv).CBC().Pkcs7Padding().Base64().Decrypt([]byte(""))
+	assert.Error(t, err)
+}
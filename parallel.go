@@ -0,0 +1,50 @@
+package encrypt
+
+import (
+	"runtime"
+	"sync"
+)
+
+// defaultParallelThreshold 小于这个长度时单协程处理更快
+const defaultParallelThreshold = 64 << 10
+
+// parallelize 把 length 字节按分组对齐切分给 GOMAXPROCS 个协程, 每段调用 fn(start, end).
+// threshold <= 0 或输入较短时直接处理
+func parallelize(length, blockSize, threshold int, fn func(start, end int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if threshold <= 0 || length < threshold || workers < 2 {
+		fn(0, length)
+		return
+	}
+
+	blocks := (length + blockSize - 1) / blockSize
+	chunk := (blocks + workers - 1) / workers * blockSize
+
+	var wg sync.WaitGroup
+	for start := 0; start < length; start += chunk {
+		end := start + chunk
+		if end > length {
+			end = length
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+
+	wg.Wait()
+}
+
+// addCounter 返回大端整数 counter + n, 与 CTR 模式一样在整个分组范围内回绕
+func addCounter(counter []byte, n uint64) []byte {
+	result := append([]byte(nil), counter...)
+	for i := len(result) - 1; i >= 0 && n > 0; i-- {
+		sum := uint64(result[i]) + n&0xff
+		result[i] = byte(sum)
+		n = n>>8 + sum>>8
+	}
+
+	return result
+}
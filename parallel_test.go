@@ -0,0 +1,76 @@
+package encrypt
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCounter(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 1, 0}, addCounter([]byte{0, 0, 0, 0xff}, 1))
+	assert.Equal(t, []byte{0, 1, 0, 0xfe}, addCounter([]byte{0, 0, 0xff, 0xff}, 0xff))
+	// 与 CTR 一样在整个计数器范围内回绕
+	assert.Equal(t, []byte{0, 0, 0, 1}, addCounter([]byte{0xff, 0xff, 0xff, 0xff}, 2))
+
+	counter := []byte{1, 2, 3}
+	addCounter(counter, 300)
+	assert.Equal(t, []byte{1, 2, 3}, counter)
+}
+
+func TestParallel(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	overflow := bytes.Repeat([]byte{0xff}, 16)
+	overflow[0] = 0x7f
+
+	for _, size := range []int{0, 16, 160, 4096, 100000} {
+		text := make([]byte, size)
+		random.Read(text)
+
+		for name, pair := range map[string][2]IEncrypt{
+			"ecb":          {NewAes(key, nil).ECB(), NewAes(key, nil).Parallel(16).ECB()},
+			"ecb-pkcs7":    {NewAes(key, nil).ECB().Pkcs7Padding(), NewAes(key, nil).Parallel(16).ECB().Pkcs7Padding()},
+			"ctr":          {NewAes(key, iv).CTR(), NewAes(key, iv).Parallel(16).CTR()},
+			"ctr-overflow": {NewAes(key, overflow).CTR(), NewAes(key, overflow).Parallel(1).CTR()},
+			"ctr-default":  {NewAes(key, iv).CTR().Hex(), NewAes(key, iv).Parallel(0).CTR().Hex()},
+			"des-ctr":      {NewDes(key[:8], iv[:8]).CTR(), NewDes(key[:8], iv[:8]).Parallel(8).CTR()},
+		} {
+			serial, parallel := pair[0], pair[1]
+			expected := mustEncrypt(t, serial, text)
+
+			encrypted := mustEncrypt(t, parallel, text)
+			assert.Equal(t, expected, encrypted, "%s size %d", name, size)
+
+			appended, err := parallel.EncryptAppend([]byte("x"), text)
+			assert.NoError(t, err)
+			assert.Equal(t, append([]byte("x"), expected...), appended, "%s size %d", name, size)
+
+			decrypted, err := parallel.Decrypt(encrypted)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(text, decrypted), "%s size %d", name, size)
+		}
+	}
+
+	// 长度不是分组整数倍时最后一段也要一致
+	text := make([]byte, 1001)
+	random.Read(text)
+	expected := mustEncrypt(t, NewAes(key, overflow).CTR(), text)
+	assert.Equal(t, expected, mustEncrypt(t, NewAes(key, overflow).Parallel(1).CTR(), text))
+}
+
+func BenchmarkParallelCtr(b *testing.B) {
+	text := make([]byte, 1<<20)
+	for name, encryptor := range map[string]IEncrypt{
+		"serial":   NewAes(key, iv).CTR(),
+		"parallel": NewAes(key, iv).Parallel(0).CTR(),
+	} {
+		b.Run(name, func(b *testing.B) {
+			dst := make([]byte, 0, len(text))
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				dst, _ = encryptor.EncryptAppend(dst[:0], text)
+			}
+		})
+	}
+}
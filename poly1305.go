@@ -0,0 +1,140 @@
+package encrypt
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	poly1305KeySize = 32
+	poly1305TagSize = 16
+
+	poly1305RMask0 = 0x0FFFFFFC0FFFFFFF
+	poly1305RMask1 = 0x0FFFFFFC0FFFFFFC
+)
+
+// poly1305 一次性消息认证码, 累加器 h 以 2^130-5 为模
+type poly1305 struct {
+	r   [2]uint64
+	s   [2]uint64
+	h   [3]uint64
+	buf [poly1305TagSize]byte
+	n   int
+}
+
+func newPoly1305(key []byte) *poly1305 {
+	p := &poly1305{}
+	p.r[0] = binary.LittleEndian.Uint64(key[0:]) & poly1305RMask0
+	p.r[1] = binary.LittleEndian.Uint64(key[8:]) & poly1305RMask1
+	p.s[0] = binary.LittleEndian.Uint64(key[16:])
+	p.s[1] = binary.LittleEndian.Uint64(key[24:])
+	return p
+}
+
+func (p *poly1305) Write(data []byte) (int, error) {
+	n := len(data)
+	if p.n > 0 {
+		c := copy(p.buf[p.n:], data)
+		p.n += c
+		data = data[c:]
+		if p.n < poly1305TagSize {
+			return n, nil
+		}
+
+		p.block(p.buf[:], 1)
+		p.n = 0
+	}
+
+	for len(data) >= poly1305TagSize {
+		p.block(data[:poly1305TagSize], 1)
+		data = data[poly1305TagSize:]
+	}
+
+	p.n = copy(p.buf[:], data)
+	return n, nil
+}
+
+// pad16 用零补齐到 16 字节边界, 供 AEAD 构造使用
+func (p *poly1305) pad16() {
+	if p.n == 0 {
+		return
+	}
+
+	for i := p.n; i < poly1305TagSize; i++ {
+		p.buf[i] = 0
+	}
+
+	p.block(p.buf[:], 1)
+	p.n = 0
+}
+
+func (p *poly1305) block(m []byte, hibit uint64) {
+	var c uint64
+	h0, h1, h2 := p.h[0], p.h[1], p.h[2]
+	r0, r1 := p.r[0], p.r[1]
+
+	h0, c = bits.Add64(h0, binary.LittleEndian.Uint64(m[0:]), 0)
+	h1, c = bits.Add64(h1, binary.LittleEndian.Uint64(m[8:]), c)
+	h2 += c + hibit
+
+	h0r0hi, h0r0lo := bits.Mul64(h0, r0)
+	h1r0hi, h1r0lo := bits.Mul64(h1, r0)
+	h0r1hi, h0r1lo := bits.Mul64(h0, r1)
+	h1r1hi, h1r1lo := bits.Mul64(h1, r1)
+	h2r0 := h2 * r0
+	h2r1 := h2 * r1
+
+	t0 := h0r0lo
+	t1, c := bits.Add64(h0r0hi, h1r0lo, 0)
+	t2, c2 := bits.Add64(h1r0hi, h1r1lo, c)
+	t3 := h1r1hi + c2
+
+	t1, c = bits.Add64(t1, h0r1lo, 0)
+	t2, c = bits.Add64(t2, h0r1hi, c)
+	t3 += c
+
+	t2, c = bits.Add64(t2, h2r0, 0)
+	t3 += h2r1 + c
+
+	// 2^130 ≡ 5, 高位部分乘 5 回加到低位
+	h0, c = bits.Add64(t0, t2&^3, 0)
+	h1, c = bits.Add64(t1, t3, c)
+	h2 = t2&3 + c
+
+	h0, c = bits.Add64(h0, t2>>2|t3<<62, 0)
+	h1, c = bits.Add64(h1, t3>>2, c)
+	h2 += c
+
+	p.h[0], p.h[1], p.h[2] = h0, h1, h2
+}
+
+func (p *poly1305) Sum(out []byte) []byte {
+	if p.n > 0 {
+		p.buf[p.n] = 1
+		for i := p.n + 1; i < poly1305TagSize; i++ {
+			p.buf[i] = 0
+		}
+
+		p.block(p.buf[:], 0)
+		p.n = 0
+	}
+
+	h0, h1, h2 := p.h[0], p.h[1], p.h[2]
+	g0, b := bits.Sub64(h0, 0xFFFFFFFFFFFFFFFB, 0)
+	g1, b := bits.Sub64(h1, 0xFFFFFFFFFFFFFFFF, b)
+	_, b = bits.Sub64(h2, 3, b)
+
+	// 无借位说明 h >= p, 取 h - p
+	mask := b - 1
+	h0 = h0&^mask | g0&mask
+	h1 = h1&^mask | g1&mask
+
+	var c uint64
+	h0, c = bits.Add64(h0, p.s[0], 0)
+	h1, _ = bits.Add64(h1, p.s[1], c)
+
+	var tag [poly1305TagSize]byte
+	binary.LittleEndian.PutUint64(tag[0:], h0)
+	binary.LittleEndian.PutUint64(tag[8:], h1)
+	return append(out, tag[:]...)
+}
@@ -0,0 +1,83 @@
+package encrypt
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const polyvalBlockSize = 16
+
+// polyval RFC 8452 中的 POLYVAL, 小端序表示 GF(2^128),
+// 约减多项式为 x^128 + x^127 + x^126 + x^121 + 1
+type polyval struct {
+	hLo, hHi uint64
+	sLo, sHi uint64
+}
+
+func newPolyval(h []byte) *polyval {
+	return &polyval{
+		hLo: binary.LittleEndian.Uint64(h[0:]),
+		hHi: binary.LittleEndian.Uint64(h[8:]),
+	}
+}
+
+// polyvalDot 计算 a * b * x^-128, Karatsuba 做三次 64 位无进位乘法, 再做两轮 Montgomery 约减
+func polyvalDot(aLo, aHi, bLo, bHi uint64) (rLo, rHi uint64) {
+	lHi, lLo := polyvalMul64(aLo, bLo)
+	hHi, hLo := polyvalMul64(aHi, bHi)
+	mHi, mLo := polyvalMul64(aLo^aHi, bLo^bHi)
+	mHi ^= lHi ^ hHi
+	mLo ^= lLo ^ hLo
+
+	// 256 位乘积 d3:d2:d1:d0
+	d0, d1, d2, d3 := lLo, lHi^mLo, hLo^mHi, hHi
+
+	// P 的低 64 位为 1, 每轮加上 d0 * P 消去最低的 64 位再右移
+	d1 ^= d0<<63 ^ d0<<62 ^ d0<<57
+	d2 ^= d0 ^ d0>>1 ^ d0>>2 ^ d0>>7
+	d2 ^= d1<<63 ^ d1<<62 ^ d1<<57
+	d3 ^= d1 ^ d1>>1 ^ d1>>2 ^ d1>>7
+	return d2, d3
+}
+
+// polyvalMul64 常数时间的 64 位无进位乘法, 高 64 位由位反转后的低 64 位得到
+func polyvalMul64(x, y uint64) (hi, lo uint64) {
+	lo = polyvalMulLow(x, y)
+	hi = bits.Reverse64(polyvalMulLow(bits.Reverse64(x), bits.Reverse64(y))) >> 1
+	return
+}
+
+// polyvalMulLow 无进位乘积的低 64 位, 按每 4 位取一位拆成 4 组做整数乘法,
+// 每组内的进位落在其他组的位上, 最后用掩码去掉
+func polyvalMulLow(x, y uint64) uint64 {
+	const m0, m1, m2, m3 = 0x1111111111111111, 0x2222222222222222, 0x4444444444444444, 0x8888888888888888
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := x0*y0 ^ x1*y3 ^ x2*y2 ^ x3*y1
+	z1 := x0*y1 ^ x1*y0 ^ x2*y3 ^ x3*y2
+	z2 := x0*y2 ^ x1*y1 ^ x2*y0 ^ x3*y3
+	z3 := x0*y3 ^ x1*y2 ^ x2*y1 ^ x3*y0
+	return z0&m0 | z1&m1 | z2&m2 | z3&m3
+}
+
+// update 按 16 字节分块累加, 不足一块的尾部补零
+func (p *polyval) update(data []byte) {
+	var block [polyvalBlockSize]byte
+	for len(data) > 0 {
+		n := copy(block[:], data)
+		for i := n; i < polyvalBlockSize; i++ {
+			block[i] = 0
+		}
+
+		data = data[n:]
+		p.sLo ^= binary.LittleEndian.Uint64(block[0:])
+		p.sHi ^= binary.LittleEndian.Uint64(block[8:])
+		p.sLo, p.sHi = polyvalDot(p.sLo, p.sHi, p.hLo, p.hHi)
+	}
+}
+
+func (p *polyval) sum() (out [polyvalBlockSize]byte) {
+	binary.LittleEndian.PutUint64(out[0:], p.sLo)
+	binary.LittleEndian.PutUint64(out[8:], p.sHi)
+	return
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package encrypt
+
+// sync.Pool 在 race 模式下会随机丢弃对象, 分配次数不准确
+const raceEnabled = true
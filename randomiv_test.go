@@ -0,0 +1,111 @@
+package encrypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomIv(t *testing.T) {
+	encryptors := map[string]IEncrypt{
+		"cbc":     NewAes(key, nil).RandomIv().CBC().Pkcs7Padding().Base64(),
+		"ctr":     NewAes(key, nil).RandomIv().CTR().Base64(),
+		"ofb":     NewAes(key, nil).RandomIv().OFB().Base64(),
+		"cfb":     NewAes(key, nil).RandomIv().CFB().Base64(),
+		"cfb8":    NewAes(key, nil).RandomIv().CFB8().Base64(),
+		"cts":     NewAes(key, nil).RandomIv().CBCCTS(Cs3).Base64(),
+		"gcm":     NewAes(key, nil).RandomIv().GCM().Additional([]byte("header")).Base64(),
+		"ccm":     NewAes(key, nil).RandomIv().CCM().Base64(),
+		"gcm-siv": NewAes(key, nil).RandomIv().GCMSIV().Base64(),
+		"ocb":     NewAes(key, nil).RandomIv().OCB().Base64(),
+		"eax":     NewAes(key, nil).RandomIv().EAX().Base64(),
+		"des-cbc": NewDes(key[:8], nil).RandomIv().CBC().Pkcs7Padding().Hex(),
+	}
+
+	for name, encryptor := range encryptors {
+		t.Run(name, func(t *testing.T) {
+			// testMethod 的随机输入可能不足一个分组, CTS 只使用下面的固定输入
+			if name != "cts" {
+				testMethod(t, encryptor, false, nil)
+			}
+
+			text := []byte("1234567890abcdefghijklmnopqrstuvw")
+			first, second := mustEncrypt(t, encryptor, text), mustEncrypt(t, encryptor, text)
+			assert.NotEqual(t, first, second)
+
+			decrypted, err := encryptor.Decrypt(second)
+			assert.NoError(t, err)
+			assert.Equal(t, text, decrypted)
+		})
+	}
+}
+
+func TestRandomIvPrefix(t *testing.T) {
+	text := []byte("1234567890abcdef")
+	encrypted := mustEncrypt(t, NewAes(key, nil).RandomIv().CBC(), text)
+	assert.Len(t, encrypted, 16+len(text))
+
+	// 前缀即为 iv, 使用固定 iv 的 CBC 可以解出同样的明文
+	decrypted, err := NewAes(key, encrypted[:16]).CBC().Decrypt(encrypted[16:])
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+
+	encrypted = mustEncrypt(t, NewAes(key, nil).RandomIv().GCM(), text)
+	assert.Len(t, encrypted, gcmStandardNonceSize+len(text)+gcmTagSize)
+
+	_, err = NewAes(key, nil).RandomIv().GCM().Decrypt(encrypted[:8])
+	assert.ErrorIs(t, err, ErrIvPrefix)
+}
+
+func TestRandomIvAead(t *testing.T) {
+	sivKey := append(append([]byte{}, key...), iv...)
+	cases := []struct {
+		name      string
+		encryptor IEncrypt
+		nonceSize int
+		tagSize   int
+	}{
+		{"gcm", NewAes(key, nil).RandomIv().GCM().Additional([]byte("header")), gcmStandardNonceSize, gcmTagSize},
+		{"gcm-short-tag", NewAes(key, nil).RandomIv().GCM().NonceSize(8).TagSize(12), 8, 12},
+		{"ccm", NewAes(key, nil).RandomIv().CCM().NonceSize(7).TagSize(8), 7, 8},
+		{"ocb", NewAes(key, nil).RandomIv().OCB(), ocbDefaultNonceSize, ocbDefaultTagSize},
+		{"eax", NewAes(key, nil).RandomIv().EAX().Additional([]byte("header")), eaxDefaultNonceSize, eaxDefaultTagSize},
+		{"gcm-siv", NewAes(key, nil).RandomIv().GCMSIV(), gcmSivNonceSize, gcmSivTagSize},
+		{"siv", NewAes(sivKey, nil).RandomIv().SIV().NonceSize(16).Additional([]byte("a"), []byte("b")), 16, sivTagSize},
+		{"secretbox", NewSecretBox(make([]byte, 32), nil), secretBoxNonceSize, poly1305TagSize},
+	}
+
+	text := []byte("1234567890abcdefghijklmnopqrstuvw")
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			encrypted := mustEncrypt(t, c.encryptor, text)
+			assert.Len(t, encrypted, c.nonceSize+len(text)+c.tagSize)
+			assert.NotEqual(t, encrypted, mustEncrypt(t, c.encryptor, text))
+
+			decrypted, err := c.encryptor.Decrypt(encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, text, decrypted)
+
+			// 不足 nonce 长度时无法读取前缀
+			for _, size := range []int{0, 1, c.nonceSize - 1} {
+				_, err = c.encryptor.Decrypt(encrypted[:size])
+				assert.ErrorIs(t, err, ErrIvPrefix, "size %d", size)
+			}
+
+			reader, err := NewDecryptReader(bytes.NewReader(encrypted[:c.nonceSize-1]), c.encryptor)
+			assert.NoError(t, err)
+			_, err = io.ReadAll(reader)
+			assert.ErrorIs(t, err, ErrIvPrefix)
+
+			// 只有前缀或前缀被修改时认证失败
+			_, err = c.encryptor.Decrypt(encrypted[:c.nonceSize])
+			assert.ErrorIs(t, err, ErrAuthFailed)
+
+			encrypted[0] ^= 1
+			_, err = c.encryptor.Decrypt(encrypted)
+			assert.ErrorIs(t, err, ErrAuthFailed)
+		})
+	}
+}
@@ -207,7 +207,7 @@ func (r *ersa) MakeSign(hash crypto.Hash, content []byte) (string, error) {
 		return "", err
 	}
 
-	hashed := r.algo(hash, content)
+	hash, hashed := r.digest(hash, content)
 	b, err := rsa.SignPKCS1v15(rand.Reader, priKey, hash, hashed)
 	if err != nil {
 		return "", err
@@ -222,6 +222,18 @@ func (r *ersa) algo(hash crypto.Hash, content []byte) []byte {
 	return hs.Sum(nil)
 }
 
+// sm3DigestInfo SM3 的 DigestInfo 前缀, OID 1.2.156.10197.1.401
+var sm3DigestInfo = []byte{0x30, 0x30, 0x30, 0x0c, 0x06, 0x08, 0x2a, 0x81, 0x1c, 0xcf, 0x55, 0x01, 0x83, 0x11, 0x05, 0x00, 0x04, 0x20}
+
+// digest 标准库不认识 SM3, 自行拼接 DigestInfo 后按未指定摘要的方式签名
+func (r *ersa) digest(hash crypto.Hash, content []byte) (crypto.Hash, []byte) {
+	if hash == SM3 {
+		return 0, append(append([]byte(nil), sm3DigestInfo...), Sm3(content)...)
+	}
+
+	return hash, r.algo(hash, content)
+}
+
 func (r *ersa) CheckSign(hash crypto.Hash, content []byte, sign string) (err error) {
 	signature, err := base64.StdEncoding.DecodeString(sign)
 	if err != nil {
@@ -233,7 +245,7 @@ func (r *ersa) CheckSign(hash crypto.Hash, content []byte, sign string) (err err
 		return
 	}
 
-	hashed := r.algo(hash, content)
+	hash, hashed := r.digest(hash, content)
 	return rsa.VerifyPKCS1v15(pubKey, hash, hashed, signature)
 }
 
@@ -243,7 +255,7 @@ func (r *ersa) MakeSafeSign(hash crypto.Hash, content []byte) (string, error) {
 		return "", err
 	}
 
-	hashed := r.algo(hash, content)
+	hash, hashed := r.digest(hash, content)
 	b, err := rsa.SignPKCS1v15(rand.Reader, priKey, hash, hashed)
 	if err != nil {
 		return "", err
@@ -263,7 +275,7 @@ func (r *ersa) CheckSafeSign(hash crypto.Hash, content []byte, sign string) (err
 		return
 	}
 
-	hashed := r.algo(hash, content)
+	hash, hashed := r.digest(hash, content)
 	return rsa.VerifyPKCS1v15(pubKey, hash, hashed, signature)
 }
 
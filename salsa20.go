@@ -0,0 +1,97 @@
+package encrypt
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	salsaKeySize   = 32
+	salsaBlockSize = 64
+)
+
+// salsaConstants "expand 32-byte k", 位于状态的对角线 0, 5, 10, 15
+var salsaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+func salsaQuarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	b ^= bits.RotateLeft32(a+d, 7)
+	c ^= bits.RotateLeft32(b+a, 9)
+	d ^= bits.RotateLeft32(c+b, 13)
+	a ^= bits.RotateLeft32(d+c, 18)
+	return a, b, c, d
+}
+
+func salsaRounds(x *[16]uint32) {
+	for i := 0; i < 10; i++ {
+		// 列变换
+		x[0], x[4], x[8], x[12] = salsaQuarterRound(x[0], x[4], x[8], x[12])
+		x[5], x[9], x[13], x[1] = salsaQuarterRound(x[5], x[9], x[13], x[1])
+		x[10], x[14], x[2], x[6] = salsaQuarterRound(x[10], x[14], x[2], x[6])
+		x[15], x[3], x[7], x[11] = salsaQuarterRound(x[15], x[3], x[7], x[11])
+
+		// 行变换
+		x[0], x[1], x[2], x[3] = salsaQuarterRound(x[0], x[1], x[2], x[3])
+		x[5], x[6], x[7], x[4] = salsaQuarterRound(x[5], x[6], x[7], x[4])
+		x[10], x[11], x[8], x[9] = salsaQuarterRound(x[10], x[11], x[8], x[9])
+		x[15], x[12], x[13], x[14] = salsaQuarterRound(x[15], x[12], x[13], x[14])
+	}
+}
+
+// salsaInitState 填入常量和 key, 6-9 由调用方填入 nonce 和计数器
+func salsaInitState(key []byte) (state [16]uint32) {
+	state[0], state[5], state[10], state[15] = salsaConstants[0], salsaConstants[1], salsaConstants[2], salsaConstants[3]
+	for i := 0; i < 4; i++ {
+		state[1+i] = binary.LittleEndian.Uint32(key[i*4:])
+		state[11+i] = binary.LittleEndian.Uint32(key[16+i*4:])
+	}
+
+	return
+}
+
+// salsa20XORKeyStream 8 字节 nonce, 64 位计数器
+func salsa20XORKeyStream(dst, src, key, nonce []byte, counter uint64) {
+	state := salsaInitState(key)
+	state[6] = binary.LittleEndian.Uint32(nonce[0:])
+	state[7] = binary.LittleEndian.Uint32(nonce[4:])
+
+	var x [16]uint32
+	var stream [salsaBlockSize]byte
+	for len(src) > 0 {
+		state[8] = uint32(counter)
+		state[9] = uint32(counter >> 32)
+		x = state
+		salsaRounds(&x)
+		for i := range x {
+			binary.LittleEndian.PutUint32(stream[i*4:], x[i]+state[i])
+		}
+
+		n := len(src)
+		if n > salsaBlockSize {
+			n = salsaBlockSize
+		}
+
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ stream[i]
+		}
+
+		dst, src = dst[n:], src[n:]
+		counter++
+	}
+}
+
+// hSalsa20 由 key 和 16 字节 nonce 派生 XSalsa20 的子密钥, 也用于 box 的共享密钥
+func hSalsa20(key, nonce []byte) []byte {
+	x := salsaInitState(key)
+	for i := 0; i < 4; i++ {
+		x[6+i] = binary.LittleEndian.Uint32(nonce[i*4:])
+	}
+
+	salsaRounds(&x)
+
+	out := make([]byte, salsaKeySize)
+	for i, index := range [8]int{0, 5, 10, 15, 6, 7, 8, 9} {
+		binary.LittleEndian.PutUint32(out[i*4:], x[index])
+	}
+
+	return out
+}
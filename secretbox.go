@@ -0,0 +1,203 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/subtle"
+	"errors"
+	"io"
+)
+
+const (
+	secretBoxKeySize   = 32
+	secretBoxNonceSize = 24
+	boxKeySize         = 32
+)
+
+// secretBox NaCl crypto_secretbox_xsalsa20poly1305, 输出为 tag || 密文,
+// 与 libsodium 的 crypto_secretbox_easy 一致. 不支持关联数据
+type secretBox struct {
+	key []byte
+}
+
+func newSecretBox(key []byte) (cipher.AEAD, error) {
+	if len(key) != secretBoxKeySize {
+		return nil, &KeyError{Algorithm: "secretbox", Size: len(key)}
+	}
+
+	return &secretBox{key: append([]byte(nil), key...)}, nil
+}
+
+func (s *secretBox) NonceSize() int {
+	return secretBoxNonceSize
+}
+
+func (s *secretBox) Overhead() int {
+	return poly1305TagSize
+}
+
+func (s *secretBox) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != secretBoxNonceSize {
+		panic("secretbox: bad nonce length passed to Seal")
+	}
+
+	if len(additionalData) != 0 {
+		panic("secretbox: additional data is not supported")
+	}
+
+	subKey, first := s.derive(nonce)
+	defer secretBoxWipe(subKey, &first)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305TagSize)
+	tag, encrypted := out[:poly1305TagSize], out[poly1305TagSize:]
+	secretBoxXor(encrypted, plaintext, subKey, nonce, &first)
+
+	mac := newPoly1305(first[:poly1305KeySize])
+	mac.Write(encrypted)
+	mac.Sum(tag[:0])
+	return ret
+}
+
+func (s *secretBox) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != secretBoxNonceSize {
+		panic("secretbox: bad nonce length passed to Open")
+	}
+
+	if len(additionalData) != 0 {
+		panic("secretbox: additional data is not supported")
+	}
+
+	if len(ciphertext) < poly1305TagSize {
+		return nil, ErrAuthFailed
+	}
+
+	tag, encrypted := ciphertext[:poly1305TagSize], ciphertext[poly1305TagSize:]
+	subKey, first := s.derive(nonce)
+	defer secretBoxWipe(subKey, &first)
+
+	mac := newPoly1305(first[:poly1305KeySize])
+	mac.Write(encrypted)
+	if subtle.ConstantTimeCompare(mac.Sum(nil), tag) != 1 {
+		return nil, ErrAuthFailed
+	}
+
+	ret, out := sliceForAppend(dst, len(encrypted))
+	secretBoxXor(out, encrypted, subKey, nonce, &first)
+	return ret, nil
+}
+
+// derive 返回 XSalsa20 子密钥和第一个密钥流块, 块的前 32 字节作为 Poly1305 的密钥
+func (s *secretBox) derive(nonce []byte) (subKey []byte, first [salsaBlockSize]byte) {
+	subKey = hSalsa20(s.key, nonce[:16])
+	salsa20XORKeyStream(first[:], first[:], subKey, nonce[16:], 0)
+	return
+}
+
+// secretBoxWipe 用完后清除子密钥和一次性的 Poly1305 密钥
+func secretBoxWipe(subKey []byte, first *[salsaBlockSize]byte) {
+	for i := range subKey {
+		subKey[i] = 0
+	}
+
+	for i := range first {
+		first[i] = 0
+	}
+}
+
+// secretBoxXor 消息从密钥流的第 32 字节开始异或
+func secretBoxXor(dst, src, subKey, nonce []byte, first *[salsaBlockSize]byte) {
+	n := len(src)
+	if n > salsaBlockSize-poly1305KeySize {
+		n = salsaBlockSize - poly1305KeySize
+	}
+
+	for i := 0; i < n; i++ {
+		dst[i] = src[i] ^ first[poly1305KeySize+i]
+	}
+
+	if len(src) > n {
+		salsa20XORKeyStream(dst[n:], src[n:], subKey, nonce[16:], 1)
+	}
+}
+
+func secretBoxFactory(key []byte) aeadFactory {
+	return func(_ cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+		if nonceSize != secretBoxNonceSize {
+			return nil, errors.New("secretbox: invalid nonce size")
+		}
+
+		if tagSize != poly1305TagSize {
+			return nil, errors.New("secretbox: tag size must be 16")
+		}
+
+		return newSecretBox(key)
+	}
+}
+
+func NewSecretBox(key, nonce []byte) IEncrypt {
+	return must(TryNewSecretBox(key, nonce))
+}
+
+// TryNewSecretBox 与 libsodium crypto_secretbox_easy 字节兼容, key 32 字节, nonce 24 字节.
+// nonce 为 nil 时每次加密随机生成并作为密文前缀 (nonce || box)
+func TryNewSecretBox(key, nonce []byte) (IEncrypt, error) {
+	m := NewMethod(nil, nonce)
+	m.randomIv = nonce == nil
+	return newAead(m, "secretbox", secretBoxFactory(key), secretBoxNonceSize, poly1305TagSize)
+}
+
+// GenerateBoxKey 生成 crypto_box 使用的 Curve25519 密钥对
+func GenerateBoxKey(random io.Reader) (publicKey, privateKey []byte, err error) {
+	key, err := ecdh.X25519().GenerateKey(random)
+	if err != nil {
+		return
+	}
+
+	return key.PublicKey().Bytes(), key.Bytes(), nil
+}
+
+// BoxPublicKey 由私钥计算公钥, 与 crypto_scalarmult_base 一致
+func BoxPublicKey(privateKey []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, &KeyError{Algorithm: "box", Size: len(privateKey)}
+	}
+
+	return key.PublicKey().Bytes(), nil
+}
+
+// BoxSharedKey 与 crypto_box_beforenm 一致: HSalsa20(X25519(privateKey, peerPublicKey), 0)
+func BoxSharedKey(peerPublicKey, privateKey []byte) ([]byte, error) {
+	priKey, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, &KeyError{Algorithm: "box", Size: len(privateKey)}
+	}
+
+	pubKey, err := ecdh.X25519().NewPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, &KeyError{Algorithm: "box", Size: len(peerPublicKey)}
+	}
+
+	// 对方公钥为小阶点时共享密钥全为零, 返回错误
+	shared, err := priKey.ECDH(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return hSalsa20(shared, make([]byte, 16)), nil
+}
+
+func NewBox(peerPublicKey, privateKey, nonce []byte) IEncrypt {
+	return must(TryNewBox(peerPublicKey, privateKey, nonce))
+}
+
+// TryNewBox 与 libsodium crypto_box_easy 字节兼容, 使用对方的公钥和自己的私钥,
+// 双方得到相同的共享密钥后按 secretbox 加解密. nonce 为 nil 时同 TryNewSecretBox
+func TryNewBox(peerPublicKey, privateKey, nonce []byte) (IEncrypt, error) {
+	shared, err := BoxSharedKey(peerPublicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return TryNewSecretBox(shared, nonce)
+}
@@ -0,0 +1,104 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// NaCl 发行包 tests/box.c, tests/secretbox.c 中的向量
+var (
+	naclAliceSecret, _ = hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	naclAlicePublic, _ = hex.DecodeString("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	naclBobSecret, _   = hex.DecodeString("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	naclBobPublic, _   = hex.DecodeString("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+	naclFirstKey, _    = hex.DecodeString("1b27556473e985d462cd51197a9a46c76009549eac6474f206c4ee0844f68389")
+	naclNonce, _       = hex.DecodeString("69696ee955b62b73cd62bda875fc73d68219e0036b7a0b37")
+	naclMessage, _     = hex.DecodeString("be075fc53c81f2d5cf141316ebeb0c7b5228c52a4c62cbd44b66849b64244ffce5ecbaaf33bd751a1ac728d45e6c61296cdc3c01233561f41db66cce314adb310e3be8250c46f06dceea3a7fa1348057e2f6556ad6b1318a024a838f21af1fde048977eb48f59ffd4924ca1c60902e52f0a089bc76897040e082f937763848645e0705")
+	naclBox            = "f3ffc7703f9400e52a7dfb4b3d3305d98e993b9f48681273c29650ba32fc76ce48332ea7164d96a4476fb8c531a1186ac0dfc17c98dce87b4da7f011ec48c97271d2c20f9b928fe2270d6fb863d51738b48eeee314a7cc8ab932164548e526ae90224368517acfeabd6bb3732bc0e9da99832b61ca01b6de56244a9e88d5f9b37973f622a43d14a6599b1f654cb45a74e355a5"
+)
+
+func TestSalsa20(t *testing.T) {
+	// tests/stream.c: XSalsa20 密钥流前 4MiB 的 SHA-256
+	subKey := hSalsa20(naclFirstKey, naclNonce[:16])
+	stream := make([]byte, 4194304)
+	salsa20XORKeyStream(stream, stream, subKey, naclNonce[16:], 0)
+	assert.Equal(t, "eea6a7251c1e72916d11c2cb214d3c252539121d8e234e652d651fa4c8cff880", hex.EncodeToString(stream[:32]))
+	sum := sha256.Sum256(stream)
+	assert.Equal(t, "662b9d0e3463029156069b12f918691a98f7dfb2ca0393c96bbfc6b1fbd630a2", hex.EncodeToString(sum[:]))
+
+	text := []byte("Hello world!")
+	subKey = hSalsa20([]byte("this is 32-byte key for xsalsa20"), []byte("24-byte nonce for xsalsa")[:16])
+	salsa20XORKeyStream(text, text, subKey, []byte("24-byte nonce for xsalsa")[16:], 0)
+	assert.Equal(t, "002d4513843fc240c401e541", hex.EncodeToString(text))
+}
+
+func TestSecretBox(t *testing.T) {
+	box := NewSecretBox(naclFirstKey, naclNonce).Hex()
+	testMethod(t, box, false, nil)
+
+	encrypted := mustEncrypt(t, box, naclMessage)
+	assert.Equal(t, naclBox, string(encrypted))
+
+	decrypted, err := box.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, naclMessage, decrypted)
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = box.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	// 不支持关联数据, 与 Seal 一样视为误用
+	aead, err := newSecretBox(naclFirstKey)
+	assert.NoError(t, err)
+	assert.Panics(t, func() { aead.Seal(nil, naclNonce, naclMessage, []byte{1}) })
+	assert.Panics(t, func() { _, _ = aead.Open(nil, naclNonce, encrypted, []byte{1}) })
+
+	_, err = TryNewSecretBox(naclFirstKey[:16], naclNonce)
+	assert.ErrorIs(t, err, ErrKeyLength)
+
+	_, err = TryNewSecretBox(naclFirstKey, naclNonce[:12])
+	assert.ErrorIs(t, err, ErrIvLength)
+}
+
+func TestSecretBoxRandomNonce(t *testing.T) {
+	box := NewSecretBox(naclFirstKey, nil).Base64()
+	first, second := mustEncrypt(t, box, naclMessage), mustEncrypt(t, box, naclMessage)
+	assert.NotEqual(t, first, second)
+
+	decrypted, err := box.Decrypt(first)
+	assert.NoError(t, err)
+	assert.Equal(t, naclMessage, decrypted)
+}
+
+func TestBox(t *testing.T) {
+	public, err := BoxPublicKey(naclAliceSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, naclAlicePublic, public)
+
+	shared, err := BoxSharedKey(naclBobPublic, naclAliceSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, naclFirstKey, shared)
+
+	alice := NewBox(naclBobPublic, naclAliceSecret, naclNonce).Hex()
+	encrypted := mustEncrypt(t, alice, naclMessage)
+	assert.Equal(t, naclBox, string(encrypted))
+
+	bob := NewBox(naclAlicePublic, naclBobSecret, naclNonce).Hex()
+	decrypted, err := bob.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, naclMessage, decrypted)
+
+	public, private, err := GenerateBoxKey(rand.Reader)
+	assert.NoError(t, err)
+	sealed := mustEncrypt(t, NewBox(public, naclAliceSecret, nil).Base64Safe(), []byte("hello"))
+	opened, err := NewBox(naclAlicePublic, private, nil).Base64Safe().Decrypt(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), opened)
+
+	_, err = TryNewBox(make([]byte, 32), naclAliceSecret, naclNonce)
+	assert.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+const (
+	sivTagSize       = 16
+	sivMaxComponents = 126
+)
+
+// siv RFC 5297 AES-SIV, 前半个 key 用于 S2V, 后半个 key 用于 CTR
+type siv struct {
+	mac       cipher.Block
+	ctr       cipher.Block
+	nonceSize int
+}
+
+func sivFactory(macBlock, ctrBlock cipher.Block) aeadFactory {
+	return func(_ cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+		if macBlock.BlockSize() != cmacBlockSize {
+			return nil, errors.New("siv: block size must be 16")
+		}
+
+		if tagSize != sivTagSize {
+			return nil, errors.New("siv: tag size must be 16")
+		}
+
+		if nonceSize < 0 {
+			return nil, errors.New("siv: invalid nonce size")
+		}
+
+		return &siv{mac: macBlock, ctr: ctrBlock, nonceSize: nonceSize}, nil
+	}
+}
+
+// NewAesSiv AES-SIV, key 为 32, 48 或 64 字节, 两半分别用于 S2V 和 CTR.
+// 32 字节的 key 也可以使用 NewAes(key, nonce).SIV()
+func NewAesSiv(key, nonce []byte) IAead {
+	return must(TryNewAesSiv(key, nonce))
+}
+
+func TryNewAesSiv(key, nonce []byte) (IAead, error) {
+	m, err := newSplitKeyMethod("aes", "siv", aes.NewCipher, key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.TrySIV()
+}
+
+func (s *siv) NonceSize() int {
+	return s.nonceSize
+}
+
+func (s *siv) Overhead() int {
+	return sivTagSize
+}
+
+func (s *siv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	return s.sealComponents(dst, nonce, plaintext, sivComponents(additionalData))
+}
+
+func (s *siv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return s.openComponents(dst, nonce, ciphertext, sivComponents(additionalData))
+}
+
+func (s *siv) sealComponents(dst, nonce, plaintext []byte, additional [][]byte) []byte {
+	s.check(nonce, additional)
+
+	v := s.s2v(additional, nonce, plaintext)
+	ret, out := sliceForAppend(dst, sivTagSize+len(plaintext))
+	s.xorKeyStream(out[sivTagSize:], plaintext, v)
+	copy(out, v[:])
+	return ret
+}
+
+func (s *siv) openComponents(dst, nonce, ciphertext []byte, additional [][]byte) ([]byte, error) {
+	s.check(nonce, additional)
+	if len(ciphertext) < sivTagSize {
+		return nil, ErrAuthFailed
+	}
+
+	var v [sivTagSize]byte
+	copy(v[:], ciphertext)
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-sivTagSize)
+	s.xorKeyStream(out, ciphertext[sivTagSize:], v)
+
+	expected := s.s2v(additional, nonce, out)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	return ret, nil
+}
+
+func (s *siv) check(nonce []byte, additional [][]byte) {
+	if len(nonce) != s.nonceSize {
+		panic("siv: incorrect nonce length given to SIV")
+	}
+
+	if len(additional)+1 > sivMaxComponents {
+		panic("siv: too many associated data components")
+	}
+}
+
+// s2v 把关联数据、nonce 和明文压缩为合成 IV
+func (s *siv) s2v(additional [][]byte, nonce, plaintext []byte) [sivTagSize]byte {
+	var zero [sivTagSize]byte
+	d := cmacSum(s.mac, zero[:])
+
+	if len(nonce) > 0 {
+		additional = append(additional[:len(additional):len(additional)], nonce)
+	}
+
+	for _, data := range additional {
+		d = cmacDouble(d)
+		sum := cmacSum(s.mac, data)
+		subtle.XORBytes(d[:], d[:], sum[:])
+	}
+
+	if len(plaintext) >= sivTagSize {
+		split := len(plaintext) - sivTagSize
+		var last [sivTagSize]byte
+		subtle.XORBytes(last[:], plaintext[split:], d[:])
+		return cmacSum(s.mac, plaintext[:split], last[:])
+	}
+
+	d = cmacDouble(d)
+	var last [sivTagSize]byte
+	copy(last[:], plaintext)
+	last[len(plaintext)] = 0x80
+	subtle.XORBytes(last[:], last[:], d[:])
+	return cmacSum(s.mac, last[:])
+}
+
+// xorKeyStream 清除 V 的第 31 和 63 位作为 CTR 初始计数器
+func (s *siv) xorKeyStream(dst, src []byte, v [sivTagSize]byte) {
+	v[8] &= 0x7f
+	v[12] &= 0x7f
+	cipher.NewCTR(s.ctr, v[:]).XORKeyStream(dst, src)
+}
+
+func sivComponents(additionalData []byte) [][]byte {
+	if len(additionalData) == 0 {
+		return nil
+	}
+
+	return [][]byte{additionalData}
+}
@@ -0,0 +1,78 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiv(t *testing.T) {
+	sivKey := append(append([]byte{}, key...), key...)
+	siv1 := NewAes(sivKey, nil).SIV().Additional([]byte("table"), []byte("column")).Base64()
+	testMethod(t, siv1, false, nil)
+
+	// 确定性加密, 相同输入得到相同密文
+	assert.Equal(t, mustEncrypt(t, siv1, []byte("xq1_ddq")), mustEncrypt(t, siv1, []byte("xq1_ddq")))
+
+	for _, size := range []int{48, 64} {
+		siv2 := NewAesSiv(make([]byte, size), nil).Hex()
+		testMethod(t, siv2, false, nil)
+	}
+}
+
+func TestSivRfc5297(t *testing.T) {
+	// A.1 确定性认证加密
+	sivKey, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	additional, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plainText, _ := hex.DecodeString("112233445566778899aabbccddee")
+
+	siv1 := NewAes(sivKey, nil).SIV().Additional(additional).Hex()
+	encrypted := mustEncrypt(t, siv1, plainText)
+	assert.Equal(t, "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c", string(encrypted))
+
+	decrypted, err := siv1.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, plainText, decrypted)
+
+	// A.2 带 nonce 的认证加密
+	sivKey, _ = hex.DecodeString("7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f")
+	additional1, _ := hex.DecodeString("00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100")
+	additional2, _ := hex.DecodeString("102030405060708090a0")
+	nonce, _ := hex.DecodeString("09f911029d74e35bd84156c5635688c0")
+	plainText, _ = hex.DecodeString("7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553")
+
+	siv2 := NewAes(sivKey, nonce).SIV().NonceSize(len(nonce)).Additional(additional1, additional2).Hex()
+	encrypted = mustEncrypt(t, siv2, plainText)
+	assert.Equal(t, "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d", string(encrypted))
+
+	decrypted, err = siv2.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, plainText, decrypted)
+}
+
+func TestSivMismatch(t *testing.T) {
+	sivKey := make([]byte, 32)
+	siv1 := NewAes(sivKey, nil).SIV().Additional([]byte("a"), []byte("b"))
+	encrypted := mustEncrypt(t, siv1, []byte("xq1_ddq"))
+
+	// 分量顺序不同, 合成 IV 不一致
+	_, err := NewAes(sivKey, nil).SIV().Additional([]byte("b"), []byte("a")).Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = siv1.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+}
+
+func TestSivDoubleKeyOnly(t *testing.T) {
+	assert.Panics(t, func() { NewAes(make([]byte, 48), iv) })
+	assert.Panics(t, func() { NewAes(make([]byte, 20), nil) })
+	assert.Panics(t, func() { NewAesSiv(make([]byte, 20), nil) })
+
+	// 32 字节的 key 两种方式结果相同
+	sivKey := append(append([]byte{}, key...), iv...)
+	assert.Equal(t,
+		mustEncrypt(t, NewAes(sivKey, nil).SIV(), []byte("xq1_ddq")),
+		mustEncrypt(t, NewAesSiv(sivKey, nil), []byte("xq1_ddq")))
+}
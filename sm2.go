@@ -0,0 +1,376 @@
+package encrypt
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var (
+	ErrSm2PublicKey  = errors.New("sm2: invalid public key")
+	ErrSm2PrivateKey = errors.New("sm2: invalid private key")
+	ErrSm2Decrypt    = errors.New("sm2: decryption failed")
+	ErrSm2Verify     = errors.New("sm2: verification failed")
+	ErrSm2Uid        = errors.New("sm2: uid too long")
+)
+
+// Sm2Order 密文中各部分的顺序, C1 为随机点, C2 为密文, C3 为杂凑值
+type Sm2Order int
+
+const (
+	// Sm2C1C3C2 GB/T 32918 使用的顺序
+	Sm2C1C3C2 Sm2Order = iota
+	// Sm2C1C2C3 旧版标准和部分实现使用的顺序
+	Sm2C1C2C3
+)
+
+const (
+	sm2ByteSize  = 32
+	sm2PointSize = 1 + 2*sm2ByteSize
+)
+
+// sm2DefaultUid 未指定用户身份时的默认值 (GM/T 0009)
+var sm2DefaultUid = []byte("1234567812345678")
+
+var sm2Params = func() *elliptic.CurveParams {
+	hex := func(s string) *big.Int {
+		n, _ := new(big.Int).SetString(s, 16)
+		return n
+	}
+
+	return &elliptic.CurveParams{
+		Name:    "SM2-P-256",
+		BitSize: 256,
+		P:       hex("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF"),
+		N:       hex("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123"),
+		B:       hex("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93"),
+		Gx:      hex("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7"),
+		Gy:      hex("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0"),
+	}
+}()
+
+var sm2P256 = &sm2Curve{sm2Params}
+
+// Sm2P256 GB/T 32918 推荐曲线, a = p - 3. 点运算使用常量时间实现
+func Sm2P256() elliptic.Curve {
+	return sm2P256
+}
+
+type Sm2PublicKey struct {
+	elliptic.Curve
+	X, Y *big.Int
+}
+
+type Sm2PrivateKey struct {
+	Sm2PublicKey
+	D *big.Int
+}
+
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+func GenerateSm2Key(random io.Reader) (*Sm2PrivateKey, error) {
+	d, err := sm2RandScalar(random)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSm2PrivateKey(d), nil
+}
+
+// NewSm2PrivateKey 由 32 字节大端私钥创建
+func NewSm2PrivateKey(d []byte) (*Sm2PrivateKey, error) {
+	if len(d) != sm2ByteSize || !sm2ValidScalar(d) {
+		return nil, ErrSm2PrivateKey
+	}
+
+	return newSm2PrivateKey(new(big.Int).SetBytes(d)), nil
+}
+
+func newSm2PrivateKey(d *big.Int) *Sm2PrivateKey {
+	x, y := sm2P256.ScalarBaseMult(d.FillBytes(make([]byte, sm2ByteSize)))
+	return &Sm2PrivateKey{Sm2PublicKey: Sm2PublicKey{Curve: sm2P256, X: x, Y: y}, D: d}
+}
+
+// NewSm2PublicKey 由非压缩格式 04 || x || y 创建
+func NewSm2PublicKey(data []byte) (*Sm2PublicKey, error) {
+	if len(data) != sm2PointSize || data[0] != 4 {
+		return nil, ErrSm2PublicKey
+	}
+
+	x := new(big.Int).SetBytes(data[1 : 1+sm2ByteSize])
+	y := new(big.Int).SetBytes(data[1+sm2ByteSize:])
+	if !sm2P256.IsOnCurve(x, y) {
+		return nil, ErrSm2PublicKey
+	}
+
+	return &Sm2PublicKey{Curve: sm2P256, X: x, Y: y}, nil
+}
+
+// Bytes 非压缩格式 04 || x || y
+func (pub *Sm2PublicKey) Bytes() []byte {
+	return sm2AppendPoint(make([]byte, 0, sm2PointSize), pub.X, pub.Y)
+}
+
+// Bytes 32 字节大端私钥
+func (priv *Sm2PrivateKey) Bytes() []byte {
+	return priv.D.FillBytes(make([]byte, sm2ByteSize))
+}
+
+func (priv *Sm2PrivateKey) Public() *Sm2PublicKey {
+	return &priv.Sm2PublicKey
+}
+
+// Za 用户身份杂凑值 SM3(ENTL || ID || a || b || xG || yG || xA || yA), uid 为空时使用默认值
+func (pub *Sm2PublicKey) Za(uid []byte) ([]byte, error) {
+	if uid == nil {
+		uid = sm2DefaultUid
+	}
+
+	if len(uid) >= 1<<13 {
+		return nil, ErrSm2Uid
+	}
+
+	h := NewSm3()
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(uid)*8)))
+	h.Write(uid)
+	for _, v := range []*big.Int{new(big.Int).Sub(sm2P256.P, big.NewInt(3)), sm2P256.B, sm2P256.Gx, sm2P256.Gy, pub.X, pub.Y} {
+		h.Write(v.FillBytes(make([]byte, sm2ByteSize)))
+	}
+
+	return h.Sum(nil), nil
+}
+
+// digest 签名使用的 e = SM3(Za || M)
+func (pub *Sm2PublicKey) digest(msg, uid []byte) (*big.Int, error) {
+	za, err := pub.Za(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewSm3()
+	h.Write(za)
+	h.Write(msg)
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
+// Sign 签名结果为 ASN.1 DER 编码的 (r, s), uid 为空时使用默认值
+func (priv *Sm2PrivateKey) Sign(random io.Reader, msg, uid []byte) ([]byte, error) {
+	e, err := priv.digest(msg, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		k, err := sm2RandScalar(random)
+		if err != nil {
+			return nil, err
+		}
+
+		if r, s, ok := sm2Sign(priv, e, k); ok {
+			return asn1.Marshal(sm2Signature{R: r, S: s})
+		}
+	}
+}
+
+// sm2Sign k 不合适时返回 false, 需要换一个随机数. 涉及 k 和 d 的运算都在 sm2FieldN 中进行
+func sm2Sign(priv *Sm2PrivateKey, e, k *big.Int) (r, s *big.Int, ok bool) {
+	f := sm2FieldN
+	kb := k.FillBytes(make([]byte, sm2ByteSize))
+	x1, _ := sm2P256.ScalarBaseMult(kb)
+
+	// r = (e + x1) mod n
+	em, _ := f.fromBytes(e.FillBytes(make([]byte, sm2ByteSize)))
+	xm, _ := f.fromBytes(x1.FillBytes(make([]byte, sm2ByteSize)))
+	rm := f.add(&em, &xm)
+	km, _ := f.fromBytes(kb)
+	rk := f.add(&rm, &km)
+	if rm.isZero()|rk.isZero() == 1 {
+		return nil, nil, false
+	}
+
+	// s = (1 + d)^-1 * (k - r * d) mod n
+	dm, _ := f.fromBytes(priv.D.FillBytes(make([]byte, sm2ByteSize)))
+	inv := f.add(&dm, &f.one)
+	inv = f.invert(&inv)
+	sm := f.mul(&rm, &dm)
+	sm = f.sub(&km, &sm)
+	sm = f.mul(&sm, &inv)
+	if sm.isZero() == 1 {
+		return nil, nil, false
+	}
+
+	return new(big.Int).SetBytes(f.bytes(&rm)), new(big.Int).SetBytes(f.bytes(&sm)), true
+}
+
+// Verify 校验 ASN.1 DER 编码的签名, uid 与签名时一致
+func (pub *Sm2PublicKey) Verify(msg, uid, sig []byte) error {
+	var signature sm2Signature
+	if rest, err := asn1.Unmarshal(sig, &signature); err != nil || len(rest) != 0 {
+		return ErrSm2Verify
+	}
+
+	e, err := pub.digest(msg, uid)
+	if err != nil {
+		return err
+	}
+
+	if !sm2Verify(pub, e, signature.R, signature.S) {
+		return ErrSm2Verify
+	}
+
+	return nil
+}
+
+func sm2Verify(pub *Sm2PublicKey, e, r, s *big.Int) bool {
+	n := sm2P256.N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 || !sm2P256.IsOnCurve(pub.X, pub.Y) {
+		return false
+	}
+
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, n)
+	if t.Sign() == 0 {
+		return false
+	}
+
+	x1, y1 := sm2P256.ScalarBaseMult(s.Bytes())
+	x2, y2 := sm2P256.ScalarMult(pub.X, pub.Y, t.Bytes())
+	x, _ := sm2P256.Add(x1, y1, x2, y2)
+
+	x.Add(x, e)
+	x.Mod(x, n)
+	return x.Cmp(r) == 0
+}
+
+// Encrypt 公钥加密, 密文为 C1 (非压缩点) 与 C2, C3 按 order 拼接
+func (pub *Sm2PublicKey) Encrypt(random io.Reader, msg []byte, order Sm2Order) ([]byte, error) {
+	if !sm2P256.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrSm2PublicKey
+	}
+
+	for {
+		k, err := sm2RandScalar(random)
+		if err != nil {
+			return nil, err
+		}
+
+		if crypto, ok := sm2Encrypt(pub, msg, k, order); ok {
+			return crypto, nil
+		}
+	}
+}
+
+func sm2Encrypt(pub *Sm2PublicKey, msg []byte, k *big.Int, order Sm2Order) ([]byte, bool) {
+	kb := k.FillBytes(make([]byte, sm2ByteSize))
+	x1, y1 := sm2P256.ScalarBaseMult(kb)
+	x2, y2 := sm2P256.ScalarMult(pub.X, pub.Y, kb)
+
+	c2, ok := sm2XorKdf(x2, y2, msg)
+	if !ok {
+		return nil, false
+	}
+
+	crypto := sm2AppendPoint(make([]byte, 0, sm2PointSize+Sm3Size+len(msg)), x1, y1)
+	c3 := sm2Hash(x2, y2, msg)
+	if order == Sm2C1C2C3 {
+		return append(append(crypto, c2...), c3...), true
+	}
+
+	return append(append(crypto, c3...), c2...), true
+}
+
+// Decrypt 私钥解密, order 与加密时一致
+func (priv *Sm2PrivateKey) Decrypt(crypto []byte, order Sm2Order) ([]byte, error) {
+	if len(crypto) < sm2PointSize+Sm3Size {
+		return nil, ErrSm2Decrypt
+	}
+
+	c1, err := NewSm2PublicKey(crypto[:sm2PointSize])
+	if err != nil {
+		return nil, ErrSm2Decrypt
+	}
+
+	c3, c2 := crypto[sm2PointSize:sm2PointSize+Sm3Size], crypto[sm2PointSize+Sm3Size:]
+	if order == Sm2C1C2C3 {
+		c2, c3 = crypto[sm2PointSize:len(crypto)-Sm3Size], crypto[len(crypto)-Sm3Size:]
+	}
+
+	x2, y2 := sm2P256.ScalarMult(c1.X, c1.Y, priv.D.FillBytes(make([]byte, sm2ByteSize)))
+	msg, ok := sm2XorKdf(x2, y2, c2)
+	if !ok || subtle.ConstantTimeCompare(sm2Hash(x2, y2, msg), c3) != 1 {
+		return nil, ErrSm2Decrypt
+	}
+
+	return msg, nil
+}
+
+// sm2XorKdf 用 KDF(x2 || y2) 异或 src, 密钥流全为零时返回 false
+func sm2XorKdf(x2, y2 *big.Int, src []byte) ([]byte, bool) {
+	z := append(x2.FillBytes(make([]byte, sm2ByteSize)), y2.FillBytes(make([]byte, sm2ByteSize))...)
+	t := sm2Kdf(z, len(src))
+
+	var zero byte
+	for i := range t {
+		zero |= t[i]
+		t[i] ^= src[i]
+	}
+
+	return t, len(src) == 0 || zero != 0
+}
+
+// sm2Hash C3 = SM3(x2 || M || y2)
+func sm2Hash(x2, y2 *big.Int, msg []byte) []byte {
+	h := NewSm3()
+	h.Write(x2.FillBytes(make([]byte, sm2ByteSize)))
+	h.Write(msg)
+	h.Write(y2.FillBytes(make([]byte, sm2ByteSize)))
+	return h.Sum(nil)
+}
+
+// sm2Kdf GB/T 32918 的密钥派生函数, 计数器从 1 开始
+func sm2Kdf(z []byte, length int) []byte {
+	out := make([]byte, 0, length+Sm3Size)
+	h := NewSm3()
+	for counter := uint32(1); len(out) < length; counter++ {
+		h.Reset()
+		h.Write(z)
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		out = h.Sum(out)
+	}
+
+	return out[:length]
+}
+
+// sm2RandScalar 拒绝采样得到 [1, n-2] 内的随机数, 私钥与随机数 k 都使用这个范围
+func sm2RandScalar(random io.Reader) (*big.Int, error) {
+	buf := make([]byte, sm2ByteSize)
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+
+		if sm2ValidScalar(buf) {
+			return new(big.Int).SetBytes(buf), nil
+		}
+	}
+}
+
+// sm2ValidScalar 常量时间判断 1 <= d <= n-2, 保证 1 + d 可逆
+func sm2ValidScalar(d []byte) bool {
+	f := sm2FieldN
+	dm, inRange := f.fromBytes(d)
+	next := f.add(&dm, &f.one)
+	return inRange&(1^dm.isZero())&(1^next.isZero()) == 1
+}
+
+func sm2AppendPoint(dst []byte, x, y *big.Int) []byte {
+	dst = append(dst, 4)
+	dst = append(dst, x.FillBytes(make([]byte, sm2ByteSize))...)
+	return append(dst, y.FillBytes(make([]byte, sm2ByteSize))...)
+}
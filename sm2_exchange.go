@@ -0,0 +1,145 @@
+package encrypt
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var (
+	ErrSm2ExchangeState   = errors.New("sm2: key exchange not initialized")
+	ErrSm2ExchangeConfirm = errors.New("sm2: key exchange confirmation failed")
+)
+
+// Sm2KeyExchange GB/T 32918.3 密钥交换协议. 双方各自 Init 生成临时公钥发给对方,
+// 收到对方的临时公钥后 Agree 得到相同的共享密钥, 可选地交换校验值确认
+type Sm2KeyExchange struct {
+	priv      *Sm2PrivateKey
+	peer      *Sm2PublicKey
+	initiator bool
+	za, zb    []byte
+	r         *big.Int
+	ephemeral *Sm2PublicKey
+	// 协商后保存 yV 和 Hash(xV || ZA || ZB || x1 || y1 || x2 || y2) 用于计算校验值
+	y     []byte
+	inner []byte
+}
+
+// NewSm2KeyExchange initiator 为发起方 A, uid 与 peerUid 为空时使用默认值
+func NewSm2KeyExchange(priv *Sm2PrivateKey, uid []byte, peer *Sm2PublicKey, peerUid []byte, initiator bool) (*Sm2KeyExchange, error) {
+	self, err := priv.Za(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	other, err := peer.Za(peerUid)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Sm2KeyExchange{priv: priv, peer: peer, initiator: initiator, za: self, zb: other}
+	if !initiator {
+		e.za, e.zb = other, self
+	}
+
+	return e, nil
+}
+
+// Init 生成本方的临时公钥 R, 发送给对方
+func (e *Sm2KeyExchange) Init(random io.Reader) (*Sm2PublicKey, error) {
+	r, err := sm2RandScalar(random)
+	if err != nil {
+		return nil, err
+	}
+
+	e.r = r
+	e.ephemeral = newSm2PrivateKey(r).Public()
+	return e.ephemeral, nil
+}
+
+// Agree 用对方的临时公钥计算 length 字节的共享密钥, confirm 为发给对方的校验值
+// (发起方为 SA, 响应方为 SB)
+func (e *Sm2KeyExchange) Agree(peerEphemeral *Sm2PublicKey, length int) (key, confirm []byte, err error) {
+	if e.r == nil {
+		return nil, nil, ErrSm2ExchangeState
+	}
+
+	if !sm2P256.IsOnCurve(peerEphemeral.X, peerEphemeral.Y) || !sm2P256.IsOnCurve(e.peer.X, e.peer.Y) {
+		return nil, nil, ErrSm2PublicKey
+	}
+
+	// t = (d + x̄ * r) mod n, 在 sm2FieldN 中计算. V = [t](P + [x̄']R'), 余因子 h = 1
+	f := sm2FieldN
+	xm, _ := f.fromBytes(sm2Reduce(e.ephemeral.X).FillBytes(make([]byte, sm2ByteSize)))
+	rm, _ := f.fromBytes(e.r.FillBytes(make([]byte, sm2ByteSize)))
+	dm, _ := f.fromBytes(e.priv.D.FillBytes(make([]byte, sm2ByteSize)))
+	tm := f.mul(&xm, &rm)
+	tm = f.add(&tm, &dm)
+
+	x, y := sm2P256.ScalarMult(peerEphemeral.X, peerEphemeral.Y, sm2Reduce(peerEphemeral.X).Bytes())
+	x, y = sm2P256.Add(e.peer.X, e.peer.Y, x, y)
+	x, y = sm2P256.ScalarMult(x, y, f.bytes(&tm))
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, ErrSm2PublicKey
+	}
+
+	xv, yv := x.FillBytes(make([]byte, sm2ByteSize)), y.FillBytes(make([]byte, sm2ByteSize))
+	z := make([]byte, 0, 4*sm2ByteSize)
+	z = append(append(append(append(z, xv...), yv...), e.za...), e.zb...)
+	key = sm2Kdf(z, length)
+
+	ra, rb := e.ephemeral, peerEphemeral
+	if !e.initiator {
+		ra, rb = rb, ra
+	}
+
+	h := NewSm3()
+	h.Write(xv)
+	h.Write(e.za)
+	h.Write(e.zb)
+	h.Write(ra.Bytes()[1:])
+	h.Write(rb.Bytes()[1:])
+	e.y, e.inner = yv, h.Sum(nil)
+
+	if e.initiator {
+		return key, e.confirm(3), nil
+	}
+
+	return key, e.confirm(2), nil
+}
+
+// Confirm 校验对方发来的校验值
+func (e *Sm2KeyExchange) Confirm(peerConfirm []byte) error {
+	if e.inner == nil {
+		return ErrSm2ExchangeState
+	}
+
+	expected := e.confirm(3)
+	if e.initiator {
+		expected = e.confirm(2)
+	}
+
+	if subtle.ConstantTimeCompare(expected, peerConfirm) != 1 {
+		return ErrSm2ExchangeConfirm
+	}
+
+	return nil
+}
+
+// confirm Hash(prefix || yV || inner), 响应方 SB 使用 0x02, 发起方 SA 使用 0x03
+func (e *Sm2KeyExchange) confirm(prefix byte) []byte {
+	h := NewSm3()
+	h.Write([]byte{prefix})
+	h.Write(e.y)
+	h.Write(e.inner)
+	return h.Sum(nil)
+}
+
+// sm2Reduce x̄ = 2^127 + (x & (2^127 - 1))
+func sm2Reduce(x *big.Int) *big.Int {
+	w := new(big.Int).Lsh(big.NewInt(1), 127)
+	r := new(big.Int).Sub(w, big.NewInt(1))
+	r.And(r, x)
+	return r.Add(r, w)
+}
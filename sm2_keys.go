@@ -0,0 +1,313 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	oidEcPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSm2         = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+var (
+	ErrSm2KeyFormat     = errors.New("sm2: unsupported key format")
+	ErrSm2PrivateKeyPem = errors.New("sm2: invalid private key PEM")
+)
+
+type sm2PrivateKeyInfo struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// sm2EcPrivateKey SEC1 的 ECPrivateKey
+type sm2EcPrivateKey struct {
+	Version    int
+	PrivateKey []byte
+	Curve      asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey  asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+type sm2PublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func sm2Algorithm() pkix.AlgorithmIdentifier {
+	params, _ := asn1.Marshal(oidSm2)
+	return pkix.AlgorithmIdentifier{Algorithm: oidEcPublicKey, Parameters: asn1.RawValue{FullBytes: params}}
+}
+
+// isSm2Algorithm id-ecPublicKey 加 SM2 曲线参数, 也接受直接使用 SM2 OID 的写法
+func isSm2Algorithm(algorithm pkix.AlgorithmIdentifier) bool {
+	if algorithm.Algorithm.Equal(oidSm2) {
+		return true
+	}
+
+	var curve asn1.ObjectIdentifier
+	if !algorithm.Algorithm.Equal(oidEcPublicKey) {
+		return false
+	}
+
+	_, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &curve)
+	return err == nil && curve.Equal(oidSm2)
+}
+
+// MarshalSm2PrivateKey PKCS#8 编码
+func MarshalSm2PrivateKey(key *Sm2PrivateKey) ([]byte, error) {
+	ec, err := asn1.Marshal(sm2EcPrivateKey{
+		Version:    1,
+		PrivateKey: key.Bytes(),
+		PublicKey:  asn1.BitString{Bytes: key.Public().Bytes(), BitLength: 8 * sm2PointSize},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(sm2PrivateKeyInfo{Algorithm: sm2Algorithm(), PrivateKey: ec})
+}
+
+// ParseSm2PrivateKey 支持 PKCS#8 和 SEC1 (EC PRIVATE KEY) 编码
+func ParseSm2PrivateKey(der []byte) (*Sm2PrivateKey, error) {
+	var info sm2PrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err == nil && len(rest) == 0 {
+		if !isSm2Algorithm(info.Algorithm) {
+			return nil, ErrSm2KeyFormat
+		}
+
+		der = info.PrivateKey
+	}
+
+	var ec sm2EcPrivateKey
+	if rest, err := asn1.Unmarshal(der, &ec); err != nil || len(rest) != 0 || ec.Version != 1 {
+		return nil, ErrSm2KeyFormat
+	}
+
+	if len(ec.Curve) != 0 && !ec.Curve.Equal(oidSm2) {
+		return nil, ErrSm2KeyFormat
+	}
+
+	// 私钥可能省略了前导零
+	if len(ec.PrivateKey) > sm2ByteSize {
+		return nil, ErrSm2PrivateKey
+	}
+
+	return NewSm2PrivateKey(new(big.Int).SetBytes(ec.PrivateKey).FillBytes(make([]byte, sm2ByteSize)))
+}
+
+// MarshalSm2PublicKey PKIX (SubjectPublicKeyInfo) 编码
+func MarshalSm2PublicKey(key *Sm2PublicKey) ([]byte, error) {
+	return asn1.Marshal(sm2PublicKeyInfo{
+		Algorithm: sm2Algorithm(),
+		PublicKey: asn1.BitString{Bytes: key.Bytes(), BitLength: 8 * sm2PointSize},
+	})
+}
+
+func ParseSm2PublicKey(der []byte) (*Sm2PublicKey, error) {
+	var info sm2PublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 || !isSm2Algorithm(info.Algorithm) {
+		return nil, ErrSm2KeyFormat
+	}
+
+	return NewSm2PublicKey(info.PublicKey.RightAlign())
+}
+
+type Sm2Keys struct {
+	privateKey []byte
+	publicKey  []byte
+
+	parsedPubKey *Sm2PublicKey
+	parsedPriKey *Sm2PrivateKey
+}
+
+func NewInitSm2Keys(privateKey *Sm2PrivateKey, publicKey *Sm2PublicKey) *Sm2Keys {
+	return &Sm2Keys{
+		parsedPriKey: privateKey,
+		parsedPubKey: publicKey,
+	}
+}
+
+// NewSm2Keys 与 NewKeys 一样传入去掉首尾的 base64 内容, 私钥为 PKCS#8 或 SEC1, 公钥为 PKIX.
+// 只需要签名或解密时 publicKey 可以为空
+func NewSm2Keys(privateKey []byte, publicKey []byte) *Sm2Keys {
+	return &Sm2Keys{
+		privateKey: formatKey([]byte(`PRIVATE KEY`), privateKey),
+		publicKey:  formatKey([]byte(`PUBLIC KEY`), publicKey),
+	}
+}
+
+func (k *Sm2Keys) PrivateKey() (key *Sm2PrivateKey, err error) {
+	if k.parsedPriKey == nil {
+		p, _ := pem.Decode(k.privateKey)
+		if p == nil {
+			// 错误中不能带上私钥内容
+			err = ErrSm2PrivateKeyPem
+			return
+		}
+
+		k.parsedPriKey, err = ParseSm2PrivateKey(p.Bytes)
+	}
+
+	return k.parsedPriKey, err
+}
+
+// PublicKey 没有设置公钥时使用私钥对应的公钥
+func (k *Sm2Keys) PublicKey() (publicKey *Sm2PublicKey, err error) {
+	if k.parsedPubKey == nil {
+		p, _ := pem.Decode(k.publicKey)
+		if p == nil || len(p.Bytes) == 0 {
+			var priKey *Sm2PrivateKey
+			if priKey, err = k.PrivateKey(); err != nil {
+				err = fmt.Errorf("sm2: no public key: %w", err)
+				return
+			}
+
+			k.parsedPubKey = priKey.Public()
+			return k.parsedPubKey, nil
+		}
+
+		k.parsedPubKey, err = ParseSm2PublicKey(p.Bytes)
+	}
+
+	return k.parsedPubKey, err
+}
+
+// ISm2 与 IRsa 对应, 加密结果与签名使用 base64 编码, Safe 系列使用 URL 安全且无填充的 base64
+type ISm2 interface {
+	// Uid 签名使用的用户身份, 默认为 1234567812345678
+	Uid(uid []byte) ISm2
+	// Order 密文顺序, 默认为 C1C3C2
+	Order(order Sm2Order) ISm2
+
+	Encrypt(content []byte) ([]byte, error)
+	Decrypt(decrypted []byte) ([]byte, error)
+
+	SafeEncrypt(content []byte) ([]byte, error)
+	SafeDecrypt(decrypted []byte) ([]byte, error)
+
+	MakeSign(content []byte) (string, error)
+	CheckSign(content []byte, sign string) (err error)
+
+	MakeSafeSign(content []byte) (string, error)
+	CheckSafeSign(content []byte, sign string) (err error)
+}
+
+type esm2 struct {
+	key   *Sm2Keys
+	uid   []byte
+	order Sm2Order
+}
+
+func NewSm2(key *Sm2Keys) *esm2 {
+	return &esm2{key: key}
+}
+
+func (s *esm2) Uid(uid []byte) ISm2 {
+	c := *s
+	c.uid = append([]byte(nil), uid...)
+	return &c
+}
+
+func (s *esm2) Order(order Sm2Order) ISm2 {
+	c := *s
+	c.order = order
+	return &c
+}
+
+func (s *esm2) Encrypt(content []byte) ([]byte, error) {
+	return s.encrypt(content, base64.StdEncoding)
+}
+
+func (s *esm2) Decrypt(decrypted []byte) ([]byte, error) {
+	return s.decrypt(decrypted, base64.StdEncoding)
+}
+
+func (s *esm2) SafeEncrypt(content []byte) ([]byte, error) {
+	return s.encrypt(content, base64.RawURLEncoding)
+}
+
+func (s *esm2) SafeDecrypt(decrypted []byte) ([]byte, error) {
+	return s.decrypt(decrypted, base64.RawURLEncoding)
+}
+
+func (s *esm2) MakeSign(content []byte) (string, error) {
+	return s.sign(content, base64.StdEncoding)
+}
+
+func (s *esm2) CheckSign(content []byte, sign string) (err error) {
+	return s.verify(content, sign, base64.StdEncoding)
+}
+
+func (s *esm2) MakeSafeSign(content []byte) (string, error) {
+	return s.sign(content, base64.RawURLEncoding)
+}
+
+func (s *esm2) CheckSafeSign(content []byte, sign string) (err error) {
+	return s.verify(content, sign, base64.RawURLEncoding)
+}
+
+func (s *esm2) encrypt(content []byte, encoding *base64.Encoding) ([]byte, error) {
+	pub, err := s.key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := pub.Encrypt(rand.Reader, content, s.order)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, encoding.EncodedLen(len(encrypted)))
+	encoding.Encode(ret, encrypted)
+	return ret, nil
+}
+
+func (s *esm2) decrypt(decrypted []byte, encoding *base64.Encoding) ([]byte, error) {
+	priKey, err := s.key.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, encoding.DecodedLen(len(decrypted)))
+	n, err := encoding.Decode(buf, decrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return priKey.Decrypt(buf[:n], s.order)
+}
+
+func (s *esm2) sign(content []byte, encoding *base64.Encoding) (string, error) {
+	priKey, err := s.key.PrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := priKey.Sign(rand.Reader, content, s.uid)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+func (s *esm2) verify(content []byte, sign string, encoding *base64.Encoding) (err error) {
+	signature, err := encoding.DecodeString(sign)
+	if err != nil {
+		return
+	}
+
+	pubKey, err := s.key.PublicKey()
+	if err != nil {
+		return
+	}
+
+	return pubKey.Verify(content, s.uid, signature)
+}
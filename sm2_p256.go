@@ -0,0 +1,447 @@
+package encrypt
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// sm2Elem 256 位整数, 小端 64 位字, 在 sm2Field 中以 Montgomery 形式 (a * 2^256 mod m) 保存
+type sm2Elem [4]uint64
+
+// sm2Field 模 m 的常量时间算术, m 为奇数且大于 2^255. 曲线的坐标使用模 p,
+// 签名和密钥交换的标量运算使用模 n
+type sm2Field struct {
+	m   sm2Elem
+	inv uint64 // -m^-1 mod 2^64
+	rr  sm2Elem
+	one sm2Elem
+	exp []byte // m - 2, 用于费马小定理求逆
+}
+
+func newSm2Field(m *big.Int) *sm2Field {
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	word := new(big.Int).Lsh(big.NewInt(1), 64)
+
+	inv := new(big.Int).ModInverse(new(big.Int).Mod(m, word), word)
+	return &sm2Field{
+		m:   sm2ElemFromBig(m),
+		inv: -inv.Uint64(),
+		rr:  sm2ElemFromBig(new(big.Int).Mod(new(big.Int).Mul(r, r), m)),
+		one: sm2ElemFromBig(new(big.Int).Mod(r, m)),
+		exp: new(big.Int).Sub(m, big.NewInt(2)).Bytes(),
+	}
+}
+
+func sm2ElemFromBig(n *big.Int) sm2Elem {
+	return sm2ElemFromBytes(n.FillBytes(make([]byte, sm2ByteSize)))
+}
+
+// sm2ElemFromBytes 32 字节大端整数, 不做约化
+func sm2ElemFromBytes(b []byte) (e sm2Elem) {
+	for i := range e {
+		e[i] = binary.BigEndian.Uint64(b[sm2ByteSize-8*(i+1):])
+	}
+
+	return
+}
+
+// sm2Select cond 为 1 时返回 a, 为 0 时返回 b
+func sm2Select(a, b *sm2Elem, cond int) (e sm2Elem) {
+	mask := -uint64(cond)
+	for i := range e {
+		e[i] = a[i]&mask | b[i]&^mask
+	}
+
+	return
+}
+
+// isZero 返回 1 表示为零
+func (e *sm2Elem) isZero() int {
+	v := e[0] | e[1] | e[2] | e[3]
+	return int(1 ^ (v|-v)>>63)
+}
+
+// reduce t < 2m 时返回 t mod m, carry 为 t 的第 257 位
+func (f *sm2Field) reduce(t *sm2Elem, carry uint64) sm2Elem {
+	var d sm2Elem
+	var borrow uint64
+	d[0], borrow = bits.Sub64(t[0], f.m[0], 0)
+	d[1], borrow = bits.Sub64(t[1], f.m[1], borrow)
+	d[2], borrow = bits.Sub64(t[2], f.m[2], borrow)
+	d[3], borrow = bits.Sub64(t[3], f.m[3], borrow)
+	_, borrow = bits.Sub64(carry, 0, borrow)
+
+	// 有借位说明 t < m, 保留 t
+	return sm2Select(t, &d, int(borrow))
+}
+
+func (f *sm2Field) add(a, b *sm2Elem) sm2Elem {
+	var t sm2Elem
+	var carry uint64
+	t[0], carry = bits.Add64(a[0], b[0], 0)
+	t[1], carry = bits.Add64(a[1], b[1], carry)
+	t[2], carry = bits.Add64(a[2], b[2], carry)
+	t[3], carry = bits.Add64(a[3], b[3], carry)
+	return f.reduce(&t, carry)
+}
+
+func (f *sm2Field) sub(a, b *sm2Elem) sm2Elem {
+	var t sm2Elem
+	var borrow uint64
+	t[0], borrow = bits.Sub64(a[0], b[0], 0)
+	t[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	t[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	t[3], borrow = bits.Sub64(a[3], b[3], borrow)
+
+	// 有借位时加回 m
+	mask := -borrow
+	var carry uint64
+	t[0], carry = bits.Add64(t[0], f.m[0]&mask, 0)
+	t[1], carry = bits.Add64(t[1], f.m[1]&mask, carry)
+	t[2], carry = bits.Add64(t[2], f.m[2]&mask, carry)
+	t[3], _ = bits.Add64(t[3], f.m[3]&mask, carry)
+	return t
+}
+
+// mul Montgomery 乘法 a * b * 2^-256 mod m (CIOS)
+func (f *sm2Field) mul(a, b *sm2Elem) sm2Elem {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		var c uint64
+		for j := 0; j < 4; j++ {
+			t[j], c = sm2MulAdd(a[j], b[i], t[j], c)
+		}
+
+		var carry uint64
+		t[4], carry = bits.Add64(t[4], c, 0)
+		t[5] = carry
+
+		m := t[0] * f.inv
+		_, c = sm2MulAdd(m, f.m[0], t[0], 0)
+		for j := 1; j < 4; j++ {
+			t[j-1], c = sm2MulAdd(m, f.m[j], t[j], c)
+		}
+
+		t[3], carry = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + carry
+	}
+
+	return f.reduce(&sm2Elem{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+// sm2MulAdd x * y + t + c, 返回低 64 位和高 64 位
+func sm2MulAdd(x, y, t, c uint64) (lo, hi uint64) {
+	hi, lo = bits.Mul64(x, y)
+	var carry uint64
+	lo, carry = bits.Add64(lo, t, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	return
+}
+
+// invert a^(m-2), 指数是公开的, 运算次数与 a 无关
+func (f *sm2Field) invert(a *sm2Elem) sm2Elem {
+	r := f.one
+	for _, b := range f.exp {
+		for i := 7; i >= 0; i-- {
+			r = f.mul(&r, &r)
+			if b>>i&1 == 1 {
+				r = f.mul(&r, a)
+			}
+		}
+	}
+
+	return r
+}
+
+// fromBytes 32 字节大端整数转为 Montgomery 形式, 不小于 m 时减去一次 m,
+// inRange 为 1 表示原值小于 m
+func (f *sm2Field) fromBytes(b []byte) (e sm2Elem, inRange int) {
+	v := sm2ElemFromBytes(b)
+	var borrow uint64
+	_, borrow = bits.Sub64(v[0], f.m[0], 0)
+	_, borrow = bits.Sub64(v[1], f.m[1], borrow)
+	_, borrow = bits.Sub64(v[2], f.m[2], borrow)
+	_, borrow = bits.Sub64(v[3], f.m[3], borrow)
+
+	v = f.reduce(&v, 0)
+	return f.mul(&v, &f.rr), int(borrow)
+}
+
+// bytes 32 字节大端
+func (f *sm2Field) bytes(a *sm2Elem) []byte {
+	one := sm2Elem{1}
+	v := f.mul(a, &one)
+	out := make([]byte, sm2ByteSize)
+	for i := range v {
+		binary.BigEndian.PutUint64(out[sm2ByteSize-8*(i+1):], v[i])
+	}
+
+	return out
+}
+
+var (
+	sm2FieldP = newSm2Field(sm2Params.P)
+	sm2FieldN = newSm2Field(sm2Params.N)
+	sm2B, _   = sm2FieldP.fromBytes(sm2Params.B.FillBytes(make([]byte, sm2ByteSize)))
+)
+
+// sm2Point 射影坐标 (X:Y:Z), 对应仿射坐标 (X/Z, Y/Z), 无穷远点为 (0:1:0)
+type sm2Point struct {
+	x, y, z sm2Elem
+}
+
+func sm2Identity() sm2Point {
+	return sm2Point{y: sm2FieldP.one}
+}
+
+// sm2NewPoint 仿射坐标创建, 坐标超出范围或不在曲线上时 ok 为 false
+func sm2NewPoint(x, y []byte) (p sm2Point, ok bool) {
+	f := sm2FieldP
+	xm, xOk := f.fromBytes(x)
+	ym, yOk := f.fromBytes(y)
+
+	// y^2 = x^3 - 3x + b
+	rhs := f.mul(&xm, &xm)
+	rhs = f.mul(&rhs, &xm)
+	three := f.add(&xm, &xm)
+	three = f.add(&three, &xm)
+	rhs = f.sub(&rhs, &three)
+	rhs = f.add(&rhs, &sm2B)
+	lhs := f.mul(&ym, &ym)
+	diff := f.sub(&lhs, &rhs)
+
+	return sm2Point{x: xm, y: ym, z: f.one}, xOk&yOk&diff.isZero() == 1
+}
+
+// affine 返回 32 字节大端的仿射坐标, 无穷远点返回 false
+func (p *sm2Point) affine() (x, y []byte, ok bool) {
+	f := sm2FieldP
+	inv := f.invert(&p.z)
+	xm, ym := f.mul(&p.x, &inv), f.mul(&p.y, &inv)
+	return f.bytes(&xm), f.bytes(&ym), p.z.isZero() == 0
+}
+
+// add 完全加法公式, 对无穷远点和相同的点都成立.
+// Renes, Costello, Batina, "Complete addition formulas for prime order elliptic curves", 算法 4 (a = -3)
+func (p *sm2Point) add(q *sm2Point) sm2Point {
+	f := sm2FieldP
+	t0 := f.mul(&p.x, &q.x)
+	t1 := f.mul(&p.y, &q.y)
+	t2 := f.mul(&p.z, &q.z)
+	t3 := f.add(&p.x, &p.y)
+	t4 := f.add(&q.x, &q.y)
+	t3 = f.mul(&t3, &t4)
+	t4 = f.add(&t0, &t1)
+	t3 = f.sub(&t3, &t4)
+	t4 = f.add(&p.y, &p.z)
+	x3 := f.add(&q.y, &q.z)
+	t4 = f.mul(&t4, &x3)
+	x3 = f.add(&t1, &t2)
+	t4 = f.sub(&t4, &x3)
+	x3 = f.add(&p.x, &p.z)
+	y3 := f.add(&q.x, &q.z)
+	x3 = f.mul(&x3, &y3)
+	y3 = f.add(&t0, &t2)
+	y3 = f.sub(&x3, &y3)
+	z3 := f.mul(&sm2B, &t2)
+	x3 = f.sub(&y3, &z3)
+	z3 = f.add(&x3, &x3)
+	x3 = f.add(&x3, &z3)
+	z3 = f.sub(&t1, &x3)
+	x3 = f.add(&t1, &x3)
+	y3 = f.mul(&sm2B, &y3)
+	t1 = f.add(&t2, &t2)
+	t2 = f.add(&t1, &t2)
+	y3 = f.sub(&y3, &t2)
+	y3 = f.sub(&y3, &t0)
+	t1 = f.add(&y3, &y3)
+	y3 = f.add(&t1, &y3)
+	t1 = f.add(&t0, &t0)
+	t0 = f.add(&t1, &t0)
+	t0 = f.sub(&t0, &t2)
+	t1 = f.mul(&t4, &y3)
+	t2 = f.mul(&t0, &y3)
+	y3 = f.mul(&x3, &z3)
+	y3 = f.add(&y3, &t2)
+	x3 = f.mul(&t3, &x3)
+	x3 = f.sub(&x3, &t1)
+	z3 = f.mul(&t4, &z3)
+	t1 = f.mul(&t3, &t0)
+	z3 = f.add(&z3, &t1)
+	return sm2Point{x: x3, y: y3, z: z3}
+}
+
+// double 同一篇论文的算法 6 (a = -3)
+func (p *sm2Point) double() sm2Point {
+	f := sm2FieldP
+	t0 := f.mul(&p.x, &p.x)
+	t1 := f.mul(&p.y, &p.y)
+	t2 := f.mul(&p.z, &p.z)
+	t3 := f.mul(&p.x, &p.y)
+	t3 = f.add(&t3, &t3)
+	z3 := f.mul(&p.x, &p.z)
+	z3 = f.add(&z3, &z3)
+	y3 := f.mul(&sm2B, &t2)
+	y3 = f.sub(&y3, &z3)
+	x3 := f.add(&y3, &y3)
+	y3 = f.add(&x3, &y3)
+	x3 = f.sub(&t1, &y3)
+	y3 = f.add(&t1, &y3)
+	y3 = f.mul(&x3, &y3)
+	x3 = f.mul(&x3, &t3)
+	t3 = f.add(&t2, &t2)
+	t2 = f.add(&t2, &t3)
+	z3 = f.mul(&sm2B, &z3)
+	z3 = f.sub(&z3, &t2)
+	z3 = f.sub(&z3, &t0)
+	t3 = f.add(&z3, &z3)
+	z3 = f.add(&z3, &t3)
+	t3 = f.add(&t0, &t0)
+	t0 = f.add(&t3, &t0)
+	t0 = f.sub(&t0, &t2)
+	t0 = f.mul(&t0, &z3)
+	y3 = f.add(&y3, &t0)
+	t0 = f.mul(&p.y, &p.z)
+	t0 = f.add(&t0, &t0)
+	z3 = f.mul(&t0, &z3)
+	x3 = f.sub(&x3, &z3)
+	z3 = f.mul(&t0, &t1)
+	z3 = f.add(&z3, &z3)
+	z3 = f.add(&z3, &z3)
+	return sm2Point{x: x3, y: y3, z: z3}
+}
+
+func sm2PointSelect(a, b *sm2Point, cond int) sm2Point {
+	return sm2Point{x: sm2Select(&a.x, &b.x, cond), y: sm2Select(&a.y, &b.y, cond), z: sm2Select(&a.z, &b.z, cond)}
+}
+
+// sm2Table 0 到 15 倍点, 查表时遍历全部表项
+type sm2Table [16]sm2Point
+
+func newSm2Table(p *sm2Point) *sm2Table {
+	t := new(sm2Table)
+	t[0] = sm2Identity()
+	t[1] = *p
+	for i := 2; i < len(t); i++ {
+		t[i] = t[i-1].add(p)
+	}
+
+	return t
+}
+
+func (t *sm2Table) lookup(n byte) sm2Point {
+	p := sm2Identity()
+	for i := range t {
+		p = sm2PointSelect(&t[i], &p, subtle.ConstantTimeByteEq(byte(i), n))
+	}
+
+	return p
+}
+
+// scalarMult 4 位固定窗口, 对 32 字节标量的每一位都执行相同的运算
+func (t *sm2Table) scalarMult(scalar []byte) sm2Point {
+	p := sm2Identity()
+	for _, b := range scalar {
+		for _, window := range [2]byte{b >> 4, b & 0xf} {
+			p = p.double()
+			p = p.double()
+			p = p.double()
+			p = p.double()
+
+			selected := t.lookup(window)
+			p = p.add(&selected)
+		}
+	}
+
+	return p
+}
+
+var sm2GeneratorTable = func() *sm2Table {
+	g, _ := sm2NewPoint(sm2Params.Gx.FillBytes(make([]byte, sm2ByteSize)), sm2Params.Gy.FillBytes(make([]byte, sm2ByteSize)))
+	return newSm2Table(&g)
+}()
+
+// sm2Curve 曲线运算使用上面的常量时间实现, 不使用 elliptic.CurveParams 的通用大数运算.
+// 与 elliptic 包一致, (0, 0) 表示无穷远点
+type sm2Curve struct {
+	*elliptic.CurveParams
+}
+
+func (c *sm2Curve) point(x, y *big.Int) (sm2Point, bool) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return sm2Identity(), true
+	}
+
+	if x.Sign() < 0 || y.Sign() < 0 || x.BitLen() > 256 || y.BitLen() > 256 {
+		return sm2Point{}, false
+	}
+
+	return sm2NewPoint(x.FillBytes(make([]byte, sm2ByteSize)), y.FillBytes(make([]byte, sm2ByteSize)))
+}
+
+func (c *sm2Curve) result(p *sm2Point) (*big.Int, *big.Int) {
+	x, y, ok := p.affine()
+	if !ok {
+		return new(big.Int), new(big.Int)
+	}
+
+	return new(big.Int).SetBytes(x), new(big.Int).SetBytes(y)
+}
+
+// scalar 标量补齐为 32 字节, 更长的标量先模 n
+func (c *sm2Curve) scalar(k []byte) []byte {
+	if len(k) > sm2ByteSize {
+		return new(big.Int).Mod(new(big.Int).SetBytes(k), c.N).FillBytes(make([]byte, sm2ByteSize))
+	}
+
+	out := make([]byte, sm2ByteSize)
+	copy(out[sm2ByteSize-len(k):], k)
+	return out
+}
+
+func (c *sm2Curve) IsOnCurve(x, y *big.Int) bool {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+
+	_, ok := c.point(x, y)
+	return ok
+}
+
+// 与 elliptic 包一致, 点不在曲线上时 panic
+func (c *sm2Curve) mustPoint(x, y *big.Int) sm2Point {
+	p, ok := c.point(x, y)
+	if !ok {
+		panic("sm2: invalid point")
+	}
+
+	return p
+}
+
+func (c *sm2Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p, q := c.mustPoint(x1, y1), c.mustPoint(x2, y2)
+	r := p.add(&q)
+	return c.result(&r)
+}
+
+func (c *sm2Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	p := c.mustPoint(x1, y1)
+	r := p.double()
+	return c.result(&r)
+}
+
+func (c *sm2Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	p := c.mustPoint(x1, y1)
+	r := newSm2Table(&p).scalarMult(c.scalar(k))
+	return c.result(&r)
+}
+
+func (c *sm2Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	r := sm2GeneratorTable.scalarMult(c.scalar(k))
+	return c.result(&r)
+}
@@ -0,0 +1,338 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 由 openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:SM2 生成
+const (
+	sm2OpensslPrivateKey = `MIGHAgEAMBMGByqGSM49AgEGCCqBHM9VAYItBG0wawIBAQQgh04RmLdcqF98oINH
+//N6+eqGv0lart2DbeFlNaevy/OhRANCAAQZtVtNXM44I2zlDuQw3VCb1/+h7rZs
+fFEBiuqm04os9po4xziTHCi7ix7cOe7het2W2Vonn00KRCHwq7gmx+3X`
+	sm2OpensslPublicKey = `MFkwEwYHKoZIzj0CAQYIKoEcz1UBgi0DQgAEGbVbTVzOOCNs5Q7kMN1Qm9f/oe62
+bHxRAYrqptOKLPaaOMc4kxwou4se3Dnu4XrdltlaJ59NCkQh8Ku4Jsft1w==`
+)
+
+func sm2Hex(s string) []byte {
+	b, _ := hex.DecodeString(strings.ToLower(s))
+	return b
+}
+
+func sm2Scalar(s string) *big.Int {
+	return new(big.Int).SetBytes(sm2Hex(s))
+}
+
+func TestSm2Vectors(t *testing.T) {
+	// GM/T 0003.5 推荐曲线上的示例
+	priv, err := NewSm2PrivateKey(sm2Hex("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0409f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020"+
+		"ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13", hex.EncodeToString(priv.Public().Bytes()))
+
+	za, err := priv.Za(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "b2e14c5c79c6df5b85f4fe7ed8db7a262b9da7e07ccb0ea9f4747b8ccda8a4f3", hex.EncodeToString(za))
+
+	k := sm2Scalar("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21")
+	e, err := priv.digest([]byte("message digest"), nil)
+	assert.NoError(t, err)
+	r, s, ok := sm2Sign(priv, e, k)
+	assert.True(t, ok)
+	assert.Equal(t, "f5a03b0648d2c4630eeac513e1bb81a15944da3827d5b74143ac7eaceee720b3", hex.EncodeToString(r.Bytes()))
+	assert.Equal(t, "b1b6aa29df212fd8763182bc0d421ca1bb9038fd1f7f42d4840b69c485bbc1aa", hex.EncodeToString(s.Bytes()))
+	assert.True(t, sm2Verify(priv.Public(), e, r, s))
+
+	c1 := "0404ebfc718e8d1798620432268e77feb6415e2ede0e073c0f4f640ecd2e149a73e8" +
+		"58f9d81e5430a57b36daab8f950a3c64e6ee6a63094d99283aff767e124df0"
+	c2 := "21886ca989ca9c7d58087307ca93092d651efa"
+	c3 := "59983c18f809e262923c53aec295d30383b54e39d609d160afcb1908d0bd8766"
+
+	crypto, ok := sm2Encrypt(priv.Public(), []byte("encryption standard"), k, Sm2C1C3C2)
+	assert.True(t, ok)
+	assert.Equal(t, c1+c3+c2, hex.EncodeToString(crypto))
+
+	crypto, _ = sm2Encrypt(priv.Public(), []byte("encryption standard"), k, Sm2C1C2C3)
+	assert.Equal(t, c1+c2+c3, hex.EncodeToString(crypto))
+
+	text, err := priv.Decrypt(sm2Hex(c1+c2+c3), Sm2C1C2C3)
+	assert.NoError(t, err)
+	assert.Equal(t, "encryption standard", string(text))
+
+	_, err = priv.Decrypt(sm2Hex(c1+c2+c3), Sm2C1C3C2)
+	assert.ErrorIs(t, err, ErrSm2Decrypt)
+}
+
+func TestSm2Curve(t *testing.T) {
+	// 常量时间实现与 elliptic.CurveParams 的通用实现结果一致
+	n := sm2Params.N
+	scalars := [][]byte{
+		{0},
+		{1},
+		{2},
+		new(big.Int).Sub(n, big.NewInt(1)).Bytes(),
+		n.Bytes(),
+		new(big.Int).Add(n, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 32),
+	}
+
+	for i := 0; i < 16; i++ {
+		k := make([]byte, 32)
+		rand.Read(k)
+		scalars = append(scalars, k)
+	}
+
+	px, py := sm2Params.ScalarBaseMult(Sm3([]byte("point")))
+	for _, k := range scalars {
+		x1, y1 := sm2P256.ScalarBaseMult(k)
+		x2, y2 := sm2Params.ScalarBaseMult(k)
+		assert.Equal(t, x2, x1, "%x", k)
+		assert.Equal(t, y2, y1, "%x", k)
+
+		x1, y1 = sm2P256.ScalarMult(px, py, k)
+		x2, y2 = sm2Params.ScalarMult(px, py, k)
+		assert.Equal(t, x2, x1, "%x", k)
+		assert.Equal(t, y2, y1, "%x", k)
+	}
+
+	// 相同的点, 互为相反数的点和无穷远点
+	x1, y1 := sm2P256.Add(px, py, px, py)
+	x2, y2 := sm2P256.Double(px, py)
+	x3, y3 := sm2Params.Double(px, py)
+	assert.Equal(t, x3, x1)
+	assert.Equal(t, y3, y1)
+	assert.Equal(t, x3, x2)
+	assert.Equal(t, y3, y2)
+
+	x1, y1 = sm2P256.Add(px, py, px, new(big.Int).Sub(sm2Params.P, py))
+	assert.Zero(t, x1.Sign()+y1.Sign())
+
+	x1, y1 = sm2P256.Add(px, py, new(big.Int), new(big.Int))
+	assert.Equal(t, px, x1)
+	assert.Equal(t, py, y1)
+
+	assert.True(t, sm2P256.IsOnCurve(px, py))
+	assert.False(t, sm2P256.IsOnCurve(px, new(big.Int).Add(py, big.NewInt(1))))
+	assert.False(t, sm2P256.IsOnCurve(new(big.Int).Add(px, sm2Params.P), py))
+
+	// 模 n 的标量运算
+	a, _ := new(big.Int).SetString("123456789abcdef0123456789abcdef0123456789abcdef", 16)
+	am, _ := sm2FieldN.fromBytes(a.FillBytes(make([]byte, 32)))
+	inv := sm2FieldN.invert(&am)
+	assert.Equal(t, new(big.Int).ModInverse(a, n), new(big.Int).SetBytes(sm2FieldN.bytes(&inv)))
+
+	product := sm2FieldN.mul(&am, &am)
+	assert.Equal(t, new(big.Int).Mod(new(big.Int).Mul(a, a), n), new(big.Int).SetBytes(sm2FieldN.bytes(&product)))
+
+	difference := sm2FieldN.sub(&sm2FieldN.one, &am)
+	assert.Equal(t, new(big.Int).Mod(new(big.Int).Sub(big.NewInt(1), a), n), new(big.Int).SetBytes(sm2FieldN.bytes(&difference)))
+
+	// 私钥范围 [1, n-2]
+	for _, c := range []struct {
+		d     *big.Int
+		valid bool
+	}{
+		{big.NewInt(0), false},
+		{big.NewInt(1), true},
+		{new(big.Int).Sub(n, big.NewInt(2)), true},
+		{new(big.Int).Sub(n, big.NewInt(1)), false},
+		{n, false},
+		{new(big.Int).SetBytes(bytes.Repeat([]byte{0xff}, 32)), false},
+	} {
+		assert.Equal(t, c.valid, sm2ValidScalar(c.d.FillBytes(make([]byte, 32))), c.d.Text(16))
+	}
+}
+
+func TestSm2ExchangeVector(t *testing.T) {
+	// GM/T 0003.5 密钥交换示例, 双方使用默认 uid
+	a, _ := NewSm2PrivateKey(sm2Hex("81EB26E941BB5AF16DF116495F90695272AE2CD63D6C4AE1678418BE48230029"))
+	b, _ := NewSm2PrivateKey(sm2Hex("785129917D45A9EA5437A59356B82338EAADDA6CEB199088F14AE10DEFA229B5"))
+	ea, err := NewSm2KeyExchange(a, nil, b.Public(), nil, true)
+	assert.NoError(t, err)
+	eb, err := NewSm2KeyExchange(b, nil, a.Public(), nil, false)
+	assert.NoError(t, err)
+
+	ea.r = sm2Scalar("D4DE15474DB74D06491C440D305E012400990F3E390C7E87153C12DB2EA60BB3")
+	ea.ephemeral = newSm2PrivateKey(ea.r).Public()
+	eb.r = sm2Scalar("7E07124814B309489125EAED101113164EBF0F3458C5BD88335C1F9D596243D6")
+	eb.ephemeral = newSm2PrivateKey(eb.r).Public()
+
+	kb, sb, err := eb.Agree(ea.ephemeral, 16)
+	assert.NoError(t, err)
+	ka, sa, err := ea.Agree(eb.ephemeral, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, "6c89347354de2484c60b4ab1fde4c6e5", hex.EncodeToString(ka))
+	assert.Equal(t, ka, kb)
+	assert.NoError(t, ea.Confirm(sb))
+	assert.NoError(t, eb.Confirm(sa))
+	assert.ErrorIs(t, ea.Confirm(sa), ErrSm2ExchangeConfirm)
+}
+
+func TestSm2Exchange(t *testing.T) {
+	a, _ := GenerateSm2Key(rand.Reader)
+	b, _ := GenerateSm2Key(rand.Reader)
+	ea, _ := NewSm2KeyExchange(a, []byte("alice"), b.Public(), []byte("bob"), true)
+	eb, _ := NewSm2KeyExchange(b, []byte("bob"), a.Public(), []byte("alice"), false)
+
+	_, _, err := ea.Agree(b.Public(), 32)
+	assert.ErrorIs(t, err, ErrSm2ExchangeState)
+
+	ra, err := ea.Init(rand.Reader)
+	assert.NoError(t, err)
+	rb, err := eb.Init(rand.Reader)
+	assert.NoError(t, err)
+
+	kb, sb, err := eb.Agree(ra, 48)
+	assert.NoError(t, err)
+	ka, sa, err := ea.Agree(rb, 48)
+	assert.NoError(t, err)
+	assert.Len(t, ka, 48)
+	assert.Equal(t, ka, kb)
+	assert.NoError(t, ea.Confirm(sb))
+	assert.NoError(t, eb.Confirm(sa))
+
+	// uid 不一致时协商出不同的密钥
+	ec, _ := NewSm2KeyExchange(b, []byte("carol"), a.Public(), []byte("alice"), false)
+	rc, _ := ec.Init(rand.Reader)
+	kc, sc, err := ec.Agree(ra, 48)
+	assert.NoError(t, err)
+	ka, _, _ = ea.Agree(rc, 48)
+	assert.NotEqual(t, ka, kc)
+	assert.Error(t, ea.Confirm(sc))
+}
+
+func TestSm2SignEncrypt(t *testing.T) {
+	priv, err := GenerateSm2Key(rand.Reader)
+	assert.NoError(t, err)
+	pub := priv.Public()
+
+	msg := []byte("sm2 message")
+	sig, err := priv.Sign(rand.Reader, msg, []byte("ALICE123@YAHOO.COM"))
+	assert.NoError(t, err)
+	assert.NoError(t, pub.Verify(msg, []byte("ALICE123@YAHOO.COM"), sig))
+	assert.ErrorIs(t, pub.Verify(msg, nil, sig), ErrSm2Verify)
+	assert.ErrorIs(t, pub.Verify([]byte("other"), []byte("ALICE123@YAHOO.COM"), sig), ErrSm2Verify)
+	assert.ErrorIs(t, pub.Verify(msg, []byte("ALICE123@YAHOO.COM"), append(sig, 0)), ErrSm2Verify)
+
+	_, err = priv.Sign(rand.Reader, msg, make([]byte, 8192))
+	assert.ErrorIs(t, err, ErrSm2Uid)
+
+	for _, size := range []int{0, 1, 31, 32, 33, 1000} {
+		text := make([]byte, size)
+		rand.Read(text)
+
+		for _, order := range []Sm2Order{Sm2C1C3C2, Sm2C1C2C3} {
+			crypto, err := pub.Encrypt(rand.Reader, text, order)
+			assert.NoError(t, err)
+			assert.Len(t, crypto, sm2PointSize+Sm3Size+size)
+
+			decrypted, err := priv.Decrypt(crypto, order)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(text, decrypted))
+
+			crypto[len(crypto)-1] ^= 1
+			_, err = priv.Decrypt(crypto, order)
+			assert.ErrorIs(t, err, ErrSm2Decrypt)
+		}
+	}
+
+	_, err = priv.Decrypt(make([]byte, 10), Sm2C1C3C2)
+	assert.ErrorIs(t, err, ErrSm2Decrypt)
+
+	_, err = NewSm2PrivateKey(sm2P256.N.Bytes())
+	assert.ErrorIs(t, err, ErrSm2PrivateKey)
+
+	_, err = NewSm2PublicKey(append([]byte{4}, make([]byte, 64)...))
+	assert.ErrorIs(t, err, ErrSm2PublicKey)
+}
+
+func TestSm2Keys(t *testing.T) {
+	keys := NewSm2Keys([]byte(sm2OpensslPrivateKey), []byte(sm2OpensslPublicKey))
+	priv, err := keys.PrivateKey()
+	assert.NoError(t, err)
+	pub, err := keys.PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, priv.Public().Bytes(), pub.Bytes())
+
+	// openssl pkeyutl -sign -rawin -digest sm3 生成的签名
+	sign := "MEUCIHtYAZ/uetnaZLC9WErsms42J0otM62yYx3diqQMOZIpAiEAi8bk5AkTRsSl0lduJQBRz3G7QjllRQF0zhEOJacSXUk="
+	sm2 := NewSm2(keys)
+	assert.NoError(t, sm2.CheckSign([]byte("openssl sm2 message"), sign))
+	assert.Error(t, sm2.Uid([]byte("ALICE123@YAHOO.COM")).CheckSign([]byte("openssl sm2 message"), sign))
+
+	sign = "MEUCIAfCgko7vt6oFK9d4POwP2LFTk+zh/TD0V8W+uMlhBKlAiEA9cVmwvaNzvcLlJ8hLmNr+oCvQrjNTPHBzcokD+L2e10="
+	assert.NoError(t, sm2.Uid([]byte("ALICE123@YAHOO.COM")).CheckSign([]byte("openssl sm2 message"), sign))
+
+	// 重新编码后与 openssl 生成的一致
+	der, err := MarshalSm2PrivateKey(priv)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(sm2OpensslPrivateKey, "\n", ""), base64.StdEncoding.EncodeToString(der))
+
+	der, err = MarshalSm2PublicKey(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(sm2OpensslPublicKey, "\n", ""), base64.StdEncoding.EncodeToString(der))
+
+	// SEC1 编码的私钥, 只有私钥时使用对应的公钥
+	p, _ := pem.Decode(formatKey([]byte("PRIVATE KEY"), []byte(sm2OpensslPrivateKey)))
+	info := p.Bytes[len(p.Bytes)-0x6d:]
+	only := NewSm2Keys([]byte(base64.StdEncoding.EncodeToString(info)), nil)
+	onlyPub, err := only.PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, pub.Bytes(), onlyPub.Bytes())
+
+	// 错误信息中不包含私钥内容
+	_, err = NewSm2Keys([]byte("c2VjcmV0=!"), nil).PrivateKey()
+	assert.ErrorIs(t, err, ErrSm2PrivateKeyPem)
+	assert.NotContains(t, err.Error(), "c2VjcmV0")
+
+	// 没有公钥时返回私钥的解析错误
+	_, err = NewSm2Keys([]byte("c2VjcmV0=!"), nil).PublicKey()
+	assert.ErrorIs(t, err, ErrSm2PrivateKeyPem)
+	assert.NotContains(t, err.Error(), "c2VjcmV0")
+
+	_, err = ParseSm2PrivateKey([]byte{0x30, 0})
+	assert.ErrorIs(t, err, ErrSm2KeyFormat)
+}
+
+func TestSm2(t *testing.T) {
+	priv, _ := GenerateSm2Key(rand.Reader)
+	sm2 := NewSm2(NewInitSm2Keys(priv, priv.Public()))
+	content := []byte("hello sm2")
+
+	for _, s := range []ISm2{sm2, sm2.Order(Sm2C1C2C3), sm2.Uid([]byte("user@example.com"))} {
+		encrypted, err := s.Encrypt(content)
+		assert.NoError(t, err)
+		decrypted, err := s.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		encrypted, err = s.SafeEncrypt(content)
+		assert.NoError(t, err)
+		decrypted, err = s.SafeDecrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		sign, err := s.MakeSign(content)
+		assert.NoError(t, err)
+		assert.NoError(t, s.CheckSign(content, sign))
+
+		sign, err = s.MakeSafeSign(content)
+		assert.NoError(t, err)
+		assert.NoError(t, s.CheckSafeSign(content, sign))
+	}
+
+	encrypted, _ := sm2.Encrypt(content)
+	_, err := sm2.Order(Sm2C1C2C3).Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrSm2Decrypt)
+
+	sign, _ := sm2.MakeSign(content)
+	assert.ErrorIs(t, sm2.Uid([]byte("other")).CheckSign(content, sign), ErrSm2Verify)
+}
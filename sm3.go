@@ -0,0 +1,162 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	Sm3Size      = 32
+	Sm3BlockSize = 64
+)
+
+// SM3 只能传给 IRsa 的 MakeSign, CheckSign, MakeSafeSign 和 CheckSafeSign.
+// 标准库的注册表无法扩展, 这个值没有注册: SM3.Available() 返回 false,
+// SM3.New() 和 SM3.Size() 会 panic, 传给标准库或其他接受 crypto.Hash 的代码都不能工作.
+// 需要 hash.Hash 时使用 NewSm3
+const SM3 crypto.Hash = 100
+
+var sm3Iv = [8]uint32{0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600, 0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e}
+
+// sm3 GB/T 32905 杂凑算法
+type sm3 struct {
+	h   [8]uint32
+	buf [Sm3BlockSize]byte
+	n   int
+	len uint64
+}
+
+func NewSm3() hash.Hash {
+	s := new(sm3)
+	s.Reset()
+	return s
+}
+
+func Sm3(data []byte) []byte {
+	h := NewSm3()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func NewHmacSm3(key []byte) hash.Hash {
+	return hmac.New(NewSm3, key)
+}
+
+func HmacSm3(key, data []byte) []byte {
+	h := NewHmacSm3(key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (s *sm3) Reset() {
+	s.h = sm3Iv
+	s.n = 0
+	s.len = 0
+}
+
+func (s *sm3) Size() int {
+	return Sm3Size
+}
+
+func (s *sm3) BlockSize() int {
+	return Sm3BlockSize
+}
+
+func (s *sm3) Write(data []byte) (int, error) {
+	n := len(data)
+	s.len += uint64(n)
+	if s.n > 0 {
+		c := copy(s.buf[s.n:], data)
+		s.n += c
+		data = data[c:]
+		if s.n < Sm3BlockSize {
+			return n, nil
+		}
+
+		s.block(s.buf[:])
+		s.n = 0
+	}
+
+	for len(data) >= Sm3BlockSize {
+		s.block(data[:Sm3BlockSize])
+		data = data[Sm3BlockSize:]
+	}
+
+	s.n = copy(s.buf[:], data)
+	return n, nil
+}
+
+// Sum 在副本上填充, 不影响继续写入
+func (s *sm3) Sum(in []byte) []byte {
+	d := *s
+	length := d.len << 3
+
+	var tail [Sm3BlockSize + 8]byte
+	tail[0] = 0x80
+	pad := 56 - d.n
+	if pad <= 0 {
+		pad += Sm3BlockSize
+	}
+
+	binary.BigEndian.PutUint64(tail[pad:], length)
+	d.Write(tail[:pad+8])
+
+	for _, v := range d.h {
+		in = binary.BigEndian.AppendUint32(in, v)
+	}
+
+	return in
+}
+
+func sm3P0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func sm3P1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+func (s *sm3) block(p []byte) {
+	var w [68]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(p[i*4:])
+	}
+
+	for j := 16; j < 68; j++ {
+		w[j] = sm3P1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, d, e, f, g, h := s.h[0], s.h[1], s.h[2], s.h[3], s.h[4], s.h[5], s.h[6], s.h[7]
+	for j := 0; j < 64; j++ {
+		var t, ff, gg uint32
+		if j < 16 {
+			t = 0x79cc4519
+			ff = a ^ b ^ c
+			gg = e ^ f ^ g
+		} else {
+			t = 0x7a879d8a
+			ff = a&b | a&c | b&c
+			gg = e&f | ^e&g
+		}
+
+		a12 := bits.RotateLeft32(a, 12)
+		ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ a12
+		tt1 := ff + d + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + h + ss1 + w[j]
+		d, c, b, a = c, bits.RotateLeft32(b, 9), a, tt1
+		h, g, f, e = g, bits.RotateLeft32(f, 19), e, sm3P0(tt2)
+	}
+
+	s.h[0] ^= a
+	s.h[1] ^= b
+	s.h[2] ^= c
+	s.h[3] ^= d
+	s.h[4] ^= e
+	s.h[5] ^= f
+	s.h[6] ^= g
+	s.h[7] ^= h
+}
@@ -0,0 +1,91 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSm3Vectors(t *testing.T) {
+	// GB/T 32905-2016 附录 A
+	assert.Equal(t, "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0", hex.EncodeToString(Sm3([]byte("abc"))))
+	assert.Equal(t, "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732",
+		hex.EncodeToString(Sm3(bytes.Repeat([]byte("abcd"), 16))))
+	assert.Equal(t, "1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b", hex.EncodeToString(Sm3(nil)))
+
+	// 分多次写入与一次写入结果相同, Sum 之后可以继续写入
+	text := bytes.Repeat([]byte("0123456789"), 30)
+	h := NewSm3()
+	for i := 0; i < len(text); i += 7 {
+		end := i + 7
+		if end > len(text) {
+			end = len(text)
+		}
+
+		h.Write(text[i:end])
+		assert.Equal(t, Sm3(text[:end]), h.Sum(nil))
+	}
+
+	h.Reset()
+	h.Write([]byte("abc"))
+	assert.Equal(t, Sm3([]byte("abc")), h.Sum(nil))
+	assert.Equal(t, Sm3Size, h.Size())
+	assert.Equal(t, Sm3BlockSize, h.BlockSize())
+}
+
+func TestHmacSm3(t *testing.T) {
+	// RFC 4231 的输入, 结果与 GmSSL 和 OpenSSL (openssl dgst -sm3 -hmac) 一致
+	cases := []struct {
+		key    []byte
+		data   []byte
+		result string
+	}{
+		{bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"), "51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70"},
+		{[]byte("Jefe"), []byte("what do ya want for nothing?"), "2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882"},
+		{bytes.Repeat([]byte{0xaa}, 20), bytes.Repeat([]byte{0xdd}, 50), "dd9421e1c725bdf52ec1aa34edadb3c97f5951a83a2fa93f73a7902bc1dcc777"},
+		// key 超过分组长度时先做杂凑
+		{bytes.Repeat([]byte{0xaa}, 131), []byte("Test Using Larger Than Block-Size Key - Hash Key First"), "b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.result, hex.EncodeToString(HmacSm3(c.key, c.data)))
+
+		mac := NewHmacSm3(c.key)
+		mac.Write(c.data)
+		assert.Equal(t, c.result, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	// 没有注册到标准库
+	assert.False(t, SM3.Available())
+}
+
+func TestRsaSm3Sign(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	r := NewRsa(NewInitKeys(priv, &priv.PublicKey))
+	content := []byte("rsa with sm3")
+	sign, err := r.MakeSign(SM3, content)
+	assert.NoError(t, err)
+	assert.NoError(t, r.CheckSign(SM3, content, sign))
+	assert.Error(t, r.CheckSign(SM3, []byte("other"), sign))
+	assert.Error(t, r.CheckSign(crypto.SHA256, content, sign))
+
+	// 签名内容是 SM3 的 DigestInfo
+	signature, _ := base64.StdEncoding.DecodeString(sign)
+	assert.NoError(t, rsa.VerifyPKCS1v15(&priv.PublicKey, 0, append(bytes.Clone(sm3DigestInfo), Sm3(content)...), signature))
+
+	safe, err := r.MakeSafeSign(SM3, content)
+	assert.NoError(t, err)
+	assert.NoError(t, r.CheckSafeSign(SM3, content, safe))
+
+	sign, err = r.MakeSign(crypto.SHA256, content)
+	assert.NoError(t, err)
+	assert.NoError(t, r.CheckSign(crypto.SHA256, content, sign))
+}
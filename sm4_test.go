@@ -0,0 +1,62 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSm4Vectors(t *testing.T) {
+	// GB/T 32907-2016 附录 A
+	sm4Key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	block, err := NewSm4Cipher(sm4Key)
+	assert.NoError(t, err)
+
+	out := make([]byte, 16)
+	block.Encrypt(out, sm4Key)
+	assert.Equal(t, "681edf34d206965e86b3e94f536e4246", hex.EncodeToString(out))
+
+	block.Decrypt(out, out)
+	assert.Equal(t, sm4Key, out)
+
+	copy(out, sm4Key)
+	for i := 0; i < 1000000; i++ {
+		block.Encrypt(out, out)
+	}
+
+	assert.Equal(t, "595298c7c6fd271f0402f804c33d3f66", hex.EncodeToString(out))
+
+	_, err = TryNewSm4(key[:10], iv)
+	assert.Equal(t, &KeyError{Algorithm: "sm4", Size: 10}, err)
+}
+
+func TestSm4Modes(t *testing.T) {
+	text := bytes.Repeat([]byte("sm4 block cipher"), 5)[:77]
+	for name, encryptor := range map[string]IEncrypt{
+		"ecb":      NewSm4(key, nil).ECB().Pkcs7Padding().Base64(),
+		"cbc":      NewSm4(key, iv).CBC().Pkcs7Padding().Hex(),
+		"ctr":      NewSm4(key, iv).CTR(),
+		"ofb":      NewSm4(key, iv).OFB(),
+		"cfb":      NewSm4(key, iv).CFB().Base64Safe(),
+		"cfb8":     NewSm4(key, iv).CFB8(),
+		"cts":      NewSm4(key, iv).CBCCTS(Cs3),
+		"gcm":      NewSm4(key, iv).GCM().Additional([]byte("header")),
+		"ccm":      NewSm4(key, iv).CCM(),
+		"random":   NewSm4(key, nil).RandomIv().CBC().Iso7816Padding(),
+		"parallel": NewSm4(key, iv).Parallel(16).CTR(),
+	} {
+		encrypted := mustEncrypt(t, encryptor, text)
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err, name)
+		assert.Equal(t, text, decrypted, name)
+	}
+
+	// GB/T 17964 的 SM4-CBC 示例
+	sm4Key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	sm4Iv, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	plain, _ := hex.DecodeString("aaaaaaaabbbbbbbbccccccccddddddddeeeeeeeeffffffffaaaaaaaabbbbbbbb")
+	encrypted := mustEncrypt(t, NewSm4(sm4Key, sm4Iv).CBC().Hex(), plain)
+	assert.Equal(t, "78ebb11cc40b0a48312aaeb2040244cb4cb7016951909226979b0d15dc6a8f6d", string(encrypted))
+}
@@ -0,0 +1,386 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// streamChunkSize 解密流每次从底层读取的长度
+const streamChunkSize = 32 << 10
+
+var ErrStreamUnsupported = errors.New("encryptor does not support streaming")
+
+// streamState 分段加解密的状态, 在 ready 允许的长度上调用 update, 结束时用 final 处理剩余部分
+type streamState interface {
+	ready(buffered int) int
+	update(dst, src []byte) ([]byte, error)
+	final(dst, src []byte) ([]byte, error)
+}
+
+// streamEncryptor 支持分段处理的加密器, 其他加密器在结束时整体处理
+type streamEncryptor interface {
+	stream(decrypt bool) (streamState, error)
+}
+
+func newStreamState(encryptor IEncryptor, decrypt bool) (streamState, error) {
+	if s, ok := encryptor.(streamEncryptor); ok {
+		return s.stream(decrypt)
+	}
+
+	return &wholeStream{encryptor: encryptor, decrypt: decrypt}, nil
+}
+
+// unitStream 每次处理 unit 的整数倍, 并在结束前至少保留 tail 个字节
+type unitStream struct {
+	unit  int
+	tail  int
+	crypt func(dst, src []byte) ([]byte, error)
+	last  func(dst, src []byte) ([]byte, error)
+}
+
+func (u *unitStream) ready(buffered int) int {
+	n := buffered - u.tail
+	if n <= 0 {
+		return 0
+	}
+
+	return n - n%u.unit
+}
+
+func (u *unitStream) update(dst, src []byte) ([]byte, error) {
+	return u.crypt(dst, src)
+}
+
+func (u *unitStream) final(dst, src []byte) ([]byte, error) {
+	if u.last != nil {
+		return u.last(dst, src)
+	}
+
+	return u.crypt(dst, src)
+}
+
+// wholeStream 认证加密等模式必须拿到完整的消息, 全部缓存到结束时处理
+type wholeStream struct {
+	encryptor IEncryptor
+	decrypt   bool
+}
+
+func (w *wholeStream) ready(int) int {
+	return 0
+}
+
+func (w *wholeStream) update(dst, src []byte) ([]byte, error) {
+	return dst, nil
+}
+
+func (w *wholeStream) final(dst, src []byte) ([]byte, error) {
+	if w.decrypt {
+		return decryptAppend(w.encryptor, dst, src)
+	}
+
+	return encryptAppend(w.encryptor, dst, src)
+}
+
+// randomIvStream 加密时先输出 iv, 解密时先读取 iv 再创建内部状态
+type randomIvStream struct {
+	size   int
+	build  func(iv []byte) IEncryptor
+	prefix []byte
+	inner  streamState
+}
+
+func (r *randomIvStream) ready(buffered int) int {
+	if r.inner == nil {
+		if buffered < r.size {
+			return 0
+		}
+
+		return r.size
+	}
+
+	return r.inner.ready(buffered)
+}
+
+func (r *randomIvStream) update(dst, src []byte) ([]byte, error) {
+	if r.inner == nil {
+		return dst, r.start(src)
+	}
+
+	dst = append(dst, r.prefix...)
+	r.prefix = nil
+	return r.inner.update(dst, src)
+}
+
+func (r *randomIvStream) final(dst, src []byte) ([]byte, error) {
+	if r.inner == nil {
+		if len(src) < r.size {
+			return nil, ErrIvPrefix
+		}
+
+		if err := r.start(src[:r.size]); err != nil {
+			return nil, err
+		}
+
+		src = src[r.size:]
+	}
+
+	dst = append(dst, r.prefix...)
+	r.prefix = nil
+	return r.inner.final(dst, src)
+}
+
+// start 解密时读到 iv 后创建内部状态
+func (r *randomIvStream) start(iv []byte) (err error) {
+	r.inner, err = newStreamState(r.build(append([]byte(nil), iv...)), true)
+	return
+}
+
+// NewEncryptWriter 把写入的明文加密后写到 w, 必须调用 Close 写出最后的分组和填充.
+// Close 不会关闭 w. 认证加密模式需要完整的消息, 会缓存到 Close 时才输出
+func NewEncryptWriter(w io.Writer, encrypt IEncrypt) (io.WriteCloser, error) {
+	b, ok := encrypt.(interface{ base() *Base })
+	if !ok {
+		return nil, ErrStreamUnsupported
+	}
+
+	base := b.base()
+	state, err := newStreamState(base.encryptor, false)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &encryptWriter{w: w, state: state, padding: base.padding, blockSize: base.blockSize()}
+	if base.wrap != nil {
+		writer.encoder = base.wrap.NewEncoder(w)
+		writer.w = writer.encoder
+	}
+
+	return writer, nil
+}
+
+// NewDecryptReader 从 r 读取密文, 返回解密后的明文流.
+// 去除填充需要保留最后的分组, 认证加密模式在读到结尾并校验通过后才输出明文
+func NewDecryptReader(r io.Reader, encrypt IEncrypt) (io.Reader, error) {
+	b, ok := encrypt.(interface{ base() *Base })
+	if !ok {
+		return nil, ErrStreamUnsupported
+	}
+
+	base := b.base()
+	state, err := newStreamState(base.encryptor, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.wrap != nil {
+		r = base.wrap.NewDecoder(r)
+	}
+
+	return &decryptReader{r: r, state: state, padding: base.padding, blockSize: base.blockSize()}, nil
+}
+
+type encryptWriter struct {
+	w         io.Writer
+	encoder   io.WriteCloser
+	state     streamState
+	padding   IPadding
+	blockSize int
+	total     int
+	buf       []byte
+	out       []byte
+	err       error
+	closed    bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	e.total += len(p)
+	e.buf = append(e.buf, p...)
+	processed := 0
+	for {
+		n := e.state.ready(len(e.buf) - processed)
+		if n == 0 {
+			break
+		}
+
+		if e.out, e.err = e.state.update(e.out[:0], e.buf[processed:processed+n]); e.err != nil {
+			return 0, e.err
+		}
+
+		processed += n
+		if e.err = e.flush(); e.err != nil {
+			return 0, e.err
+		}
+	}
+
+	e.buf = e.buf[:copy(e.buf, e.buf[processed:])]
+	return len(p), nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return e.err
+	}
+
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+
+	if e.padding != nil {
+		e.buf = append(e.buf, paddingTail(e.padding, e.total, e.blockSize)...)
+	}
+
+	if e.out, e.err = e.state.final(e.out[:0], e.buf); e.err != nil {
+		return e.err
+	}
+
+	if e.err = e.flush(); e.err != nil {
+		return e.err
+	}
+
+	if e.encoder != nil {
+		e.err = e.encoder.Close()
+	}
+
+	return e.err
+}
+
+func (e *encryptWriter) flush() error {
+	if len(e.out) == 0 {
+		return nil
+	}
+
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// paddingTail 填充只取决于总长度, 用同样长度的余数计算出需要追加的字节
+func paddingTail(padding IPadding, total, blockSize int) []byte {
+	remain := total % blockSize
+	return padding.Fill(make([]byte, remain, remain+blockSize), blockSize)[remain:]
+}
+
+type decryptReader struct {
+	r         io.Reader
+	state     streamState
+	padding   IPadding
+	blockSize int
+	chunk     []byte
+	pending   []byte
+	plain     []byte
+	plainBuf  []byte
+	available int
+	consumed  int
+	produced  int
+	err       error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for d.available == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		d.fill()
+	}
+
+	n := copy(p, d.plain[:d.available])
+	d.plain = d.plain[n:]
+	d.available -= n
+	d.consumed += n
+	return n, nil
+}
+
+func (d *decryptReader) fill() {
+	if d.chunk == nil {
+		d.chunk = make([]byte, streamChunkSize)
+	}
+
+	n, err := d.r.Read(d.chunk)
+	d.pending = append(d.pending, d.chunk[:n]...)
+
+	// 已经读走的部分不再保留
+	d.plain = append(d.plainBuf[:0], d.plain...)
+	defer func() { d.plainBuf = d.plain }()
+	processed := 0
+	for {
+		k := d.state.ready(len(d.pending) - processed)
+		if k == 0 {
+			break
+		}
+
+		before := len(d.plain)
+		if d.plain, d.err = d.state.update(d.plain, d.pending[processed:processed+k]); d.err != nil {
+			return
+		}
+
+		processed += k
+		d.produced += len(d.plain) - before
+	}
+
+	d.pending = d.pending[:copy(d.pending, d.pending[processed:])]
+
+	switch {
+	case err == io.EOF:
+		d.finish()
+	case err != nil:
+		d.err = err
+	default:
+		d.release()
+	}
+}
+
+// release 保留可能属于填充的末尾明文, 其余的可以读取
+func (d *decryptReader) release() {
+	boundary := d.produced
+	if d.padding != nil {
+		boundary -= pendingLength(d.padding, d.plain, d.blockSize)
+		boundary -= boundary % d.blockSize
+	}
+
+	if available := boundary - d.consumed; available > d.available {
+		d.available = available
+	}
+}
+
+func (d *decryptReader) finish() {
+	if d.plain, d.err = d.state.final(d.plain, d.pending); d.err != nil {
+		return
+	}
+
+	if d.padding != nil {
+		restored, err := d.padding.Restore(d.plain[d.available:], d.blockSize)
+		if err != nil {
+			d.err = err
+			return
+		}
+
+		d.plain = append(d.plain[:d.available], restored...)
+	}
+
+	d.available = len(d.plain)
+	d.err = io.EOF
+}
+
+// pendingLength 去掉填充前需要保留的明文长度: 最后一个分组,
+// 去零的填充还要保留末尾所有的零和它前面的一个字节
+func pendingLength(padding IPadding, plain []byte, blockSize int) int {
+	keep := blockSize
+	switch padding.(type) {
+	case *ZeroPadding, *LegacyNoPadding:
+		if zeros := len(plain) - len(bytes.TrimRight(plain, "\x00")); zeros+1 > keep {
+			keep = zeros + 1
+		}
+	}
+
+	return keep
+}
@@ -0,0 +1,190 @@
+package encrypt
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamEncryptors() map[string]IEncrypt {
+	return map[string]IEncrypt{
+		"ecb-pkcs7":      NewAes(key, nil).ECB().Pkcs7Padding().Base64(),
+		"cbc-pkcs7":      NewAes(key, iv).CBC().Pkcs7Padding().Base64(),
+		"cbc-zero":       NewAes(key, iv).CBC().ZeroPadding().Hex(),
+		"cbc-legacy":     NewAes(key, iv).CBC().LegacyNoPadding(),
+		"cbc-x923":       NewAes(key, iv).CBC().AnsiX923Padding().Base64Safe(),
+		"cbc-iso7816":    NewAes(key, iv).CBC().Iso7816Padding(),
+		"ctr":            NewAes(key, iv).CTR().Base64(),
+		"ctr-pkcs7":      NewAes(key, iv).CTR().Pkcs7Padding().Hex(),
+		"ofb":            NewAes(key, iv).OFB(),
+		"cfb":            NewAes(key, iv).CFB().Base64Safe(),
+		"cfb8":           NewAes(key, iv).CFB8().Hex(),
+		"cfb1":           NewAes(key, iv).CFBSegment(1),
+		"cfb24":          NewAes(key, iv).CFBSegment(24).Base64(),
+		"cts1":           NewAes(key, iv).CBCCTS(Cs1).Base64(),
+		"cts2":           NewAes(key, iv).CBCCTS(Cs2),
+		"cts3":           NewAes(key, iv).CBCCTS(Cs3).Hex(),
+		"gcm":            NewAes(key, iv).GCM().FixedNonce().Additional([]byte("header")).Base64(),
+		"eax":            NewAes(key, iv).EAX().FixedNonce(),
+		"chacha20":       NewChaCha20Poly1305(make([]byte, 32), make([]byte, 12)).Hex(),
+		"des-cbc":        NewDes(key[:8], iv[:8]).CBC().Pkcs7Padding().Base64(),
+		"random-cbc":     NewAes(key, nil).RandomIv().CBC().Pkcs7Padding().Base64(),
+		"random-ctr":     NewAes(key, nil).RandomIv().CTR(),
+		"random-cts":     NewAes(key, nil).RandomIv().CBCCTS(Cs3).Hex(),
+		"random-gcm":     NewAes(key, nil).RandomIv().GCM().Base64(),
+		"random-cfb8":    NewAes(key, nil).RandomIv().CFB8(),
+		"random-ecb-iso": NewAes(key, nil).ECB().Iso10126Padding().Base64(),
+	}
+}
+
+func streamEncrypt(t *testing.T, encryptor IEncrypt, text []byte, chunk int) []byte {
+	var buf bytes.Buffer
+	writer, err := NewEncryptWriter(&buf, encryptor)
+	assert.NoError(t, err)
+
+	for rest := text; len(rest) > 0; {
+		n := chunk
+		if n > len(rest) {
+			n = len(rest)
+		}
+
+		_, err = writer.Write(rest[:n])
+		assert.NoError(t, err)
+		rest = rest[n:]
+	}
+
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func streamDecrypt(encryptor IEncrypt, r io.Reader) ([]byte, error) {
+	reader, err := NewDecryptReader(r, encryptor)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+func TestStream(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sizes := []int{0, 1, 15, 16, 17, 31, 32, 33, 1000, 40000}
+
+	for name, encryptor := range streamEncryptors() {
+		t.Run(name, func(t *testing.T) {
+			deterministic := !strings.HasPrefix(name, "random")
+			for _, size := range sizes {
+				text := make([]byte, size)
+				random.Read(text)
+				if strings.Contains(name, "zero") || strings.Contains(name, "legacy") {
+					// 去零的填充会去掉明文末尾的零, 以非零字节结尾
+					text = append(text, 0, 0, 1)
+				}
+
+				expected, err := encryptor.Encrypt(text)
+				if err != nil {
+					// 密文窃取不支持过短的输入
+					writer, _ := NewEncryptWriter(io.Discard, encryptor)
+					_, _ = writer.Write(text)
+					assert.ErrorIs(t, writer.Close(), ErrCtsSize)
+					continue
+				}
+
+				for _, chunk := range []int{1, 7, 16, 4096} {
+					if chunk == 1 && size > 1000 {
+						continue
+					}
+
+					encrypted := streamEncrypt(t, encryptor, text, chunk)
+					if deterministic {
+						assert.Equal(t, expected, encrypted, "size %d chunk %d", size, chunk)
+					}
+
+					decrypted, err := encryptor.Decrypt(encrypted)
+					assert.NoError(t, err)
+					assert.True(t, bytes.Equal(text, decrypted), "size %d chunk %d", size, chunk)
+				}
+
+				readers := []io.Reader{
+					bytes.NewReader(expected),
+					iotest.HalfReader(bytes.NewReader(expected)),
+					iotest.DataErrReader(bytes.NewReader(expected)),
+				}
+				if size <= 1000 {
+					readers = append(readers, iotest.OneByteReader(bytes.NewReader(expected)))
+				}
+
+				for _, r := range readers {
+					decrypted, err := streamDecrypt(encryptor, r)
+					assert.NoError(t, err, "size %d", size)
+					assert.True(t, bytes.Equal(text, decrypted), "size %d", size)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamLegacyZeros(t *testing.T) {
+	// 明文末尾超过一个分组的零也要和整体解密一样去掉
+	text := append([]byte("head"), make([]byte, 40)...)
+	text = append(text, 'x')
+	text = append(text, make([]byte, 70000)...)
+
+	for _, encryptor := range []IEncrypt{
+		NewAes(key, iv).CBC().LegacyNoPadding(),
+		NewAes(key, iv).CTR().ZeroPadding(),
+	} {
+		encrypted := mustEncrypt(t, encryptor, text)
+		expected, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+
+		decrypted, err := streamDecrypt(encryptor, iotest.HalfReader(bytes.NewReader(encrypted)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, decrypted)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	encryptor := NewAes(key, iv).CBC().Pkcs7Padding()
+	encrypted := mustEncrypt(t, encryptor, []byte("hello world"))
+	encrypted[len(encrypted)-1] ^= 1
+	_, err := streamDecrypt(encryptor, bytes.NewReader(encrypted))
+	assert.ErrorIs(t, err, ErrPaddingSize)
+
+	_, err = streamDecrypt(encryptor, bytes.NewReader(encrypted[:10]))
+	assert.ErrorIs(t, err, ErrNotFullBlocks)
+
+	gcm := NewAes(key, iv).GCM()
+	encrypted = mustEncrypt(t, gcm, []byte("hello world"))
+	encrypted[0] ^= 1
+	decrypted, err := streamDecrypt(gcm, bytes.NewReader(encrypted))
+	assert.ErrorIs(t, err, ErrAuthFailed)
+	assert.Empty(t, decrypted)
+
+	_, err = streamDecrypt(NewAes(key, nil).RandomIv().CTR(), bytes.NewReader(make([]byte, 5)))
+	assert.ErrorIs(t, err, ErrIvPrefix)
+
+	_, err = NewEncryptWriter(io.Discard, nil)
+	assert.ErrorIs(t, err, ErrStreamUnsupported)
+
+	writer, err := NewEncryptWriter(io.Discard, NewAes(key, nil).ECB().NoPadding())
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("short"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, writer.Close(), ErrNotFullBlocks)
+}
+
+func TestStreamBase64SafeUnpadded(t *testing.T) {
+	encryptor := NewAes(key, iv).CTR().Base64Safe()
+	text := []byte("12345")
+	encrypted := bytes.TrimRight(mustEncrypt(t, encryptor, text), "=")
+
+	decrypted, err := streamDecrypt(encryptor, bytes.NewReader(encrypted))
+	assert.NoError(t, err)
+	assert.Equal(t, text, decrypted)
+}
@@ -2,6 +2,7 @@ package encrypt
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
@@ -31,6 +32,12 @@ func generate() []byte {
 	return b
 }
 
+func mustEncrypt(t *testing.T, encryptor IEncryptor, text []byte) []byte {
+	encrypted, err := encryptor.Encrypt(text)
+	assert.NoError(t, err)
+	return encrypted
+}
+
 func testMethod(t *testing.T, encryptor IEncrypt, useXXXX bool, result []byte) {
 	var text = make([]byte, 0)
 	if useXXXX {
@@ -39,7 +46,8 @@ func testMethod(t *testing.T, encryptor IEncrypt, useXXXX bool, result []byte) {
 		text = generate()
 	}
 
-	encrypted := encryptor.Encrypt(text)
+	encrypted, err := encryptor.Encrypt(text)
+	assert.NoError(t, err)
 	t.Logf("encrypted: %s\n", encrypted)
 	if useXXXX {
 		t.Logf("encrypted Equal <%s>: %v\n", result, bytes.Equal(encrypted, result))
@@ -52,3 +60,45 @@ func testMethod(t *testing.T, encryptor IEncrypt, useXXXX bool, result []byte) {
 	assert.NoError(t, err)
 	assert.Equal(t, text, t2)
 }
+
+func testBlockVectors(t *testing.T, newCipher cipherFunc, vectors [][3]string) {
+	for _, v := range vectors {
+		key, _ := hex.DecodeString(v[0])
+		plain, _ := hex.DecodeString(v[1])
+		block, err := newCipher(key)
+		assert.NoError(t, err)
+
+		out := make([]byte, len(plain))
+		block.Encrypt(out, plain)
+		assert.Equal(t, v[2], hex.EncodeToString(out), "key %s", v[0])
+
+		block.Decrypt(out, out)
+		assert.Equal(t, plain, out, "key %s", v[0])
+	}
+}
+
+func testBlockModes(t *testing.T, method func(key, iv []byte) IMethod, key, iv []byte) {
+	text := bytes.Repeat([]byte("block cipher modes"), 5)[:77]
+	m := method(key, iv)
+	encryptors := map[string]IEncrypt{
+		"ecb":    method(key, nil).ECB().Pkcs7Padding().Base64(),
+		"cbc":    m.CBC().Pkcs7Padding().Hex(),
+		"ctr":    m.CTR(),
+		"ofb":    m.OFB(),
+		"cfb":    m.CFB().Base64Safe(),
+		"cfb8":   m.CFB8(),
+		"cts":    m.CBCCTS(Cs3),
+		"random": method(key, nil).RandomIv().CBC().Iso7816Padding(),
+	}
+	if len(iv) == 16 {
+		encryptors["gcm"] = m.GCM().Additional([]byte("header"))
+		encryptors["eax"] = m.EAX()
+	}
+
+	for name, encryptor := range encryptors {
+		encrypted := mustEncrypt(t, encryptor, text)
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err, name)
+		assert.Equal(t, text, decrypted, name)
+	}
+}
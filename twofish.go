@@ -0,0 +1,223 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/bits"
+)
+
+const twofishBlockSize = 16
+
+// twofishQ 由 4 位置换表 t0-t3 构造的固定置换 q0, q1
+var twofishQ = func() (q [2][256]byte) {
+	t := [2][4][16]byte{
+		{
+			{0x8, 0x1, 0x7, 0xd, 0x6, 0xf, 0x3, 0x2, 0x0, 0xb, 0x5, 0x9, 0xe, 0xc, 0xa, 0x4},
+			{0xe, 0xc, 0xb, 0x8, 0x1, 0x2, 0x3, 0x5, 0xf, 0x4, 0xa, 0x6, 0x7, 0x0, 0x9, 0xd},
+			{0xb, 0xa, 0x5, 0xe, 0x6, 0xd, 0x9, 0x0, 0xc, 0x8, 0xf, 0x3, 0x2, 0x4, 0x7, 0x1},
+			{0xd, 0x7, 0xf, 0x4, 0x1, 0x2, 0x6, 0xe, 0x9, 0xb, 0x3, 0x0, 0x8, 0x5, 0xc, 0xa},
+		},
+		{
+			{0x2, 0x8, 0xb, 0xd, 0xf, 0x7, 0x6, 0xe, 0x3, 0x1, 0x9, 0x4, 0x0, 0xa, 0xc, 0x5},
+			{0x1, 0xe, 0x2, 0xb, 0x4, 0xc, 0x3, 0x7, 0x6, 0xd, 0xa, 0x5, 0xf, 0x9, 0x0, 0x8},
+			{0x4, 0xc, 0x7, 0x5, 0x1, 0x6, 0x9, 0xa, 0x0, 0xe, 0xd, 0x8, 0x2, 0xb, 0x3, 0xf},
+			{0xb, 0x9, 0x5, 0x1, 0xc, 0x3, 0xd, 0xe, 0x6, 0x4, 0x7, 0xf, 0x2, 0x0, 0x8, 0xa},
+		},
+	}
+
+	ror4 := func(x byte) byte { return (x>>1 | x<<3) & 0xf }
+	for n := range q {
+		for x := 0; x < 256; x++ {
+			a, b := byte(x>>4), byte(x&0xf)
+			a, b = a^b, a^ror4(b)^(a<<3)&0xf
+			a, b = t[n][0][a], t[n][1][b]
+			a, b = a^b, a^ror4(b)^(a<<3)&0xf
+			a, b = t[n][2][a], t[n][3][b]
+			q[n][x] = b<<4 | a
+		}
+	}
+
+	return
+}()
+
+var (
+	twofishMds = [4][4]byte{
+		{0x01, 0xef, 0x5b, 0x5b},
+		{0x5b, 0xef, 0xef, 0x01},
+		{0xef, 0x5b, 0x01, 0xef},
+		{0xef, 0x01, 0xef, 0x5b},
+	}
+	twofishRs = [4][8]byte{
+		{0x01, 0xa4, 0x55, 0x87, 0x5a, 0x58, 0xdb, 0x9e},
+		{0xa4, 0x56, 0x82, 0xf3, 0x1e, 0xc6, 0x68, 0xe5},
+		{0x02, 0xa1, 0xfc, 0xc1, 0x47, 0xae, 0x3d, 0x19},
+		{0xa4, 0x55, 0x87, 0x5a, 0x58, 0xdb, 0x9e, 0x03},
+	}
+)
+
+type twofishCipher struct {
+	k [40]uint32
+	s [4][256]uint32
+}
+
+// NewTwofishCipher Twofish 分组密码, key 为 16, 24 或 32 字节
+func NewTwofishCipher(key []byte) (cipher.Block, error) {
+	n := len(key) / 8
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, &KeyError{Algorithm: "twofish", Size: len(key)}
+	}
+
+	// 偶数字用于子密钥, RS 编码后的 S 逆序用于密钥相关的 S 盒
+	even, odd, s := make([]uint32, n), make([]uint32, n), make([]uint32, n)
+	for i := 0; i < n; i++ {
+		even[i] = binary.LittleEndian.Uint32(key[8*i:])
+		odd[i] = binary.LittleEndian.Uint32(key[8*i+4:])
+
+		var word uint32
+		for j := 0; j < 4; j++ {
+			var v byte
+			for k := 0; k < 8; k++ {
+				v ^= gfMul(twofishRs[j][k], key[8*i+k], 0x14d)
+			}
+
+			word |= uint32(v) << (8 * j)
+		}
+
+		s[n-1-i] = word
+	}
+
+	c := new(twofishCipher)
+	const rho = 0x01010101
+	for i := uint32(0); i < 20; i++ {
+		a := twofishH(2*i*rho, even)
+		b := bits.RotateLeft32(twofishH((2*i+1)*rho, odd), 8)
+		c.k[2*i] = a + b
+		c.k[2*i+1] = bits.RotateLeft32(a+2*b, 9)
+	}
+
+	for i := range c.s {
+		for x := 0; x < 256; x++ {
+			c.s[i][x] = twofishMdsColumn(i, twofishSbox(i, byte(x), s))
+		}
+	}
+
+	return c, nil
+}
+
+// twofishSbox 第 i 个字节经过的 q 置换和密钥异或
+func twofishSbox(i int, y byte, l []uint32) byte {
+	q0, q1 := &twofishQ[0], &twofishQ[1]
+	key := func(k int) byte { return byte(l[k] >> (8 * i)) }
+	// 每一层对四个字节使用的置换, 1 表示 q1
+	order := [4][4]int{
+		{1, 0, 0, 1},
+		{1, 1, 0, 0},
+		{0, 1, 0, 1},
+		{0, 0, 1, 1},
+	}
+
+	q := func(n int, x byte) byte {
+		if n == 1 {
+			return q1[x]
+		}
+
+		return q0[x]
+	}
+
+	switch len(l) {
+	case 4:
+		y = q(order[0][i], y) ^ key(3)
+		fallthrough
+	case 3:
+		y = q(order[1][i], y) ^ key(2)
+	}
+
+	y = q(order[2][i], y) ^ key(1)
+	y = q(order[3][i], y) ^ key(0)
+	return q([4]int{1, 0, 1, 0}[i], y)
+}
+
+func twofishMdsColumn(i int, y byte) uint32 {
+	var word uint32
+	for j := 0; j < 4; j++ {
+		word |= uint32(gfMul(twofishMds[j][i], y, 0x169)) << (8 * j)
+	}
+
+	return word
+}
+
+func twofishH(x uint32, l []uint32) uint32 {
+	var z uint32
+	for i := 0; i < 4; i++ {
+		z ^= twofishMdsColumn(i, twofishSbox(i, byte(x>>(8*i)), l))
+	}
+
+	return z
+}
+
+// gfMul GF(2^8) 上的乘法, poly 为约化多项式
+func gfMul(a, b byte, poly uint) byte {
+	var r uint
+	x := uint(a)
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			r ^= x
+		}
+
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= poly
+		}
+	}
+
+	return byte(r)
+}
+
+func (c *twofishCipher) g(x uint32) uint32 {
+	return c.s[0][byte(x)] ^ c.s[1][byte(x>>8)] ^ c.s[2][byte(x>>16)] ^ c.s[3][byte(x>>24)]
+}
+
+func (c *twofishCipher) BlockSize() int {
+	return twofishBlockSize
+}
+
+func (c *twofishCipher) Encrypt(dst, src []byte) {
+	a := binary.LittleEndian.Uint32(src[0:]) ^ c.k[0]
+	b := binary.LittleEndian.Uint32(src[4:]) ^ c.k[1]
+	d0 := binary.LittleEndian.Uint32(src[8:]) ^ c.k[2]
+	d1 := binary.LittleEndian.Uint32(src[12:]) ^ c.k[3]
+
+	for r := 0; r < 16; r++ {
+		t0 := c.g(a)
+		t1 := c.g(bits.RotateLeft32(b, 8))
+		d0 = bits.RotateLeft32(d0^(t0+t1+c.k[2*r+8]), -1)
+		d1 = bits.RotateLeft32(d1, 1) ^ (t0 + 2*t1 + c.k[2*r+9])
+		a, b, d0, d1 = d0, d1, a, b
+	}
+
+	// 撤销最后一轮的交换
+	binary.LittleEndian.PutUint32(dst[0:], d0^c.k[4])
+	binary.LittleEndian.PutUint32(dst[4:], d1^c.k[5])
+	binary.LittleEndian.PutUint32(dst[8:], a^c.k[6])
+	binary.LittleEndian.PutUint32(dst[12:], b^c.k[7])
+}
+
+func (c *twofishCipher) Decrypt(dst, src []byte) {
+	d0 := binary.LittleEndian.Uint32(src[0:]) ^ c.k[4]
+	d1 := binary.LittleEndian.Uint32(src[4:]) ^ c.k[5]
+	a := binary.LittleEndian.Uint32(src[8:]) ^ c.k[6]
+	b := binary.LittleEndian.Uint32(src[12:]) ^ c.k[7]
+
+	for r := 15; r >= 0; r-- {
+		a, b, d0, d1 = d0, d1, a, b
+		t0 := c.g(a)
+		t1 := c.g(bits.RotateLeft32(b, 8))
+		d0 = bits.RotateLeft32(d0, 1) ^ (t0 + t1 + c.k[2*r+8])
+		d1 = bits.RotateLeft32(d1^(t0+2*t1+c.k[2*r+9]), -1)
+	}
+
+	binary.LittleEndian.PutUint32(dst[0:], a^c.k[0])
+	binary.LittleEndian.PutUint32(dst[4:], b^c.k[1])
+	binary.LittleEndian.PutUint32(dst[8:], d0^c.k[2])
+	binary.LittleEndian.PutUint32(dst[12:], d1^c.k[3])
+}
@@ -0,0 +1,26 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwofish(t *testing.T) {
+	// Twofish 论文附带的 ECB_TBL.TXT
+	testBlockVectors(t, NewTwofishCipher, [][3]string{
+		{"00000000000000000000000000000000", "00000000000000000000000000000000", "9f589f5cf6122c32b6bfec2f2ae8c35a"},
+		{"0123456789abcdeffedcba98765432100011223344556677", "00000000000000000000000000000000", "cfd1d2e5a9be9cdf501f13b892bd2248"},
+		{"0123456789abcdeffedcba987654321000112233445566778899aabbccddeeff", "00000000000000000000000000000000", "37527be0052334b89f0cfccae87cfa20"},
+	})
+
+	testBlockModes(t, NewTwofish, key, iv)
+}
+
+func TestTwofishKeyLength(t *testing.T) {
+	// 只支持 16, 24, 32 字节的密钥
+	for _, size := range []int{0, 8, 15, 17, 23, 25, 31, 33, 48, 64} {
+		_, err := TryNewTwofish(make([]byte, size), iv)
+		assert.Equal(t, &KeyError{Algorithm: "twofish", Size: size}, err)
+	}
+}
@@ -3,80 +3,186 @@ package encrypt
 import (
 	"encoding/base64"
 	"encoding/hex"
-	"strings"
+	"io"
 )
 
+// IWrap 密文的编码方式, Append 版本把结果追加到 dst 后面
 type IWrap interface {
 	Encode([]byte) []byte
 	Decode([]byte) ([]byte, error)
+	EncodeAppend(dst, src []byte) []byte
+	DecodeAppend(dst, src []byte) ([]byte, error)
+	NewEncoder(w io.Writer) io.WriteCloser
+	NewDecoder(r io.Reader) io.Reader
 }
 
 type Base64SafeWrap struct {
 }
 
 func (b Base64SafeWrap) Encode(bytes []byte) (dst []byte) {
-	dst = make([]byte, base64.StdEncoding.EncodedLen(len(bytes)))
-	base64.StdEncoding.Encode(dst, bytes)
-	str := string(dst)
-	str = strings.Replace(str, "+", "-", -1)
-	str = strings.Replace(str, "/", "_", -1)
-	dst = []byte(str)
-	return
+	return b.EncodeAppend(nil, bytes)
 }
 
 func (b Base64SafeWrap) Decode(bytes []byte) (dst []byte, err error) {
-	var index int
-	str := string(bytes)
-	str = strings.Replace(str, "-", "+", -1)
-	str = strings.Replace(str, "_", "/", -1)
-	mod4 := len(str) % 4
-	if mod4 != 0 {
-		str = str + "===="[0:mod4]
+	return b.DecodeAppend(nil, bytes)
+}
+
+func (b Base64SafeWrap) EncodeAppend(dst, src []byte) []byte {
+	dst, out := sliceForAppend(dst, base64.StdEncoding.EncodedLen(len(src)))
+	base64.StdEncoding.Encode(out, src)
+	replaceBytes(out, '+', '-', '/', '_')
+	return dst
+}
+
+func (b Base64SafeWrap) DecodeAppend(dst, src []byte) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	std := append((*buf)[:0], src...)
+	replaceBytes(std, '-', '+', '_', '/')
+	if mod4 := len(std) % 4; mod4 != 0 {
+		std = append(std, "===="[0:mod4]...)
 	}
 
-	bs := []byte(str)
-	dst = make([]byte, base64.StdEncoding.EncodedLen(len(bs)))
-	if index, err = base64.StdEncoding.Decode(dst, bs); err != nil {
-		return
+	*buf = std
+	return Base64Wrap{}.DecodeAppend(dst, std)
+}
+
+// NewEncoder Base64Safe 与 URL 编码的字符集相同, 保留末尾的 '='
+func (b Base64SafeWrap) NewEncoder(w io.Writer) io.WriteCloser {
+	return base64.NewEncoder(base64.URLEncoding, w)
+}
+
+// NewDecoder 与 Decode 一样允许省略末尾的 '='
+func (b Base64SafeWrap) NewDecoder(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.URLEncoding, &base64PadReader{r: r})
+}
+
+// base64PadReader 读到结尾时补齐缺少的 '='
+type base64PadReader struct {
+	r     io.Reader
+	count int
+	pad   []byte
+	eof   bool
+}
+
+func (p *base64PadReader) Read(b []byte) (int, error) {
+	if p.eof {
+		if len(p.pad) == 0 {
+			return 0, io.EOF
+		}
+
+		n := copy(b, p.pad)
+		p.pad = p.pad[n:]
+		return n, nil
+	}
+
+	n, err := p.r.Read(b)
+	for _, c := range b[:n] {
+		if c != '\r' && c != '\n' {
+			p.count++
+		}
 	}
 
-	return dst[:index], nil
+	if err == io.EOF {
+		p.eof = true
+		if mod4 := p.count % 4; mod4 != 0 {
+			p.pad = []byte("===")[:4-mod4]
+		}
+
+		err = nil
+	}
+
+	return n, err
+}
+
+// replaceBytes 原地把 a 替换为 b, c 替换为 d
+func replaceBytes(s []byte, a, b, c, d byte) {
+	for i, v := range s {
+		switch v {
+		case a:
+			s[i] = b
+		case c:
+			s[i] = d
+		}
+	}
 }
 
 type Base64Wrap struct {
 }
 
 func (b Base64Wrap) Encode(bytes []byte) (dst []byte) {
-	dst = make([]byte, base64.StdEncoding.EncodedLen(len(bytes)))
-	base64.StdEncoding.Encode(dst, bytes)
-	return
+	return b.EncodeAppend(nil, bytes)
 }
 
 func (b Base64Wrap) Decode(bytes []byte) (dst []byte, err error) {
-	var index int
-	dst = make([]byte, base64.StdEncoding.EncodedLen(len(bytes)))
-	if index, err = base64.StdEncoding.Decode(dst, bytes); err != nil {
-		return
+	return b.DecodeAppend(nil, bytes)
+}
+
+func (b Base64Wrap) EncodeAppend(dst, src []byte) []byte {
+	dst, out := sliceForAppend(dst, base64.StdEncoding.EncodedLen(len(src)))
+	base64.StdEncoding.Encode(out, src)
+	return dst
+}
+
+func (b Base64Wrap) DecodeAppend(dst, src []byte) ([]byte, error) {
+	head, out := sliceForAppend(dst, base64.StdEncoding.DecodedLen(len(src)))
+	index, err := base64.StdEncoding.Decode(out, src)
+	if err != nil {
+		return nil, err
 	}
 
-	return dst[:index], nil
+	return head[:len(dst)+index], nil
+}
+
+func (b Base64Wrap) NewEncoder(w io.Writer) io.WriteCloser {
+	return base64.NewEncoder(base64.StdEncoding, w)
+}
+
+func (b Base64Wrap) NewDecoder(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, r)
 }
 
 type HexWrap struct {
 }
 
 func (h HexWrap) Encode(bytes []byte) (dst []byte) {
-	dst = make([]byte, hex.EncodedLen(len(bytes)))
-	index := hex.Encode(dst, bytes)
-	return dst[:index]
+	return h.EncodeAppend(nil, bytes)
 }
 
 func (h HexWrap) Decode(bytes []byte) (dst []byte, err error) {
-	var index int
-	dst = make([]byte, hex.DecodedLen(len(bytes)))
-	if index, err = hex.Decode(dst, bytes); err != nil {
-		return
+	return h.DecodeAppend(nil, bytes)
+}
+
+func (h HexWrap) EncodeAppend(dst, src []byte) []byte {
+	dst, out := sliceForAppend(dst, hex.EncodedLen(len(src)))
+	hex.Encode(out, src)
+	return dst
+}
+
+func (h HexWrap) DecodeAppend(dst, src []byte) ([]byte, error) {
+	head, out := sliceForAppend(dst, hex.DecodedLen(len(src)))
+	index, err := hex.Decode(out, src)
+	if err != nil {
+		return nil, err
 	}
 
-	return dst[:index], nil
+	return head[:len(dst)+index], nil
+}
+
+func (h HexWrap) NewEncoder(w io.Writer) io.WriteCloser {
+	return nopWriteCloser{hex.NewEncoder(w)}
+}
+
+func (h HexWrap) NewDecoder(r io.Reader) io.Reader {
+	return hex.NewDecoder(r)
+}
+
+// nopWriteCloser 不需要收尾的编码器
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
 }
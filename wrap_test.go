@@ -30,3 +30,21 @@ func TestHexWrapper(t *testing.T) {
 
 	assert.Equal(t, original, decoded)
 }
+
+func TestWrapAppend(t *testing.T) {
+	wraps := []IWrap{&Base64Wrap{}, &Base64SafeWrap{}, &HexWrap{}}
+	original := []byte("1234567890abcdefghijklmnopqrstuvw\xfb\xff")
+	prefix := []byte("prefix:")
+
+	for _, wrap := range wraps {
+		wrapped := wrap.EncodeAppend(append([]byte{}, prefix...), original)
+		assert.Equal(t, append(append([]byte{}, prefix...), wrap.Encode(original)...), wrapped)
+
+		decoded, err := wrap.DecodeAppend(append([]byte{}, prefix...), wrapped[len(prefix):])
+		assert.NoError(t, err)
+		assert.Equal(t, append(append([]byte{}, prefix...), original...), decoded)
+	}
+
+	_, err := (&Base64Wrap{}).DecodeAppend(nil, []byte("!!"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const xtsBlockSize = 16
+
+var (
+	ErrSectorSize      = errors.New("xts: sector size must be at least one block")
+	ErrXtsDuplicateKey = errors.New("xts: the two halves of the key must differ")
+)
+
+type IXts interface {
+	EncryptSector(sectorNum uint64, data []byte) ([]byte, error)
+	DecryptSector(sectorNum uint64, data []byte) ([]byte, error)
+}
+
+// Xts IEEE 1619 XTS, 前半个 key 加密数据, 后半个 key 加密 tweak,
+// 扇区长度不是分组整数倍时使用密文窃取
+type Xts struct {
+	data  cipher.Block
+	tweak cipher.Block
+}
+
+// NewXts AES-XTS, key 为 32 或 64 字节
+func NewXts(key []byte) IXts {
+	return must(TryNewXts(key))
+}
+
+func TryNewXts(key []byte) (IXts, error) {
+	m, err := newSplitKeyMethod("aes", "xts", aes.NewCipher, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.TryXTS()
+}
+
+func newXts(data, tweak cipher.Block) (IXts, error) {
+	if data.BlockSize() != xtsBlockSize {
+		return nil, errors.New("xts: block size must be 16")
+	}
+
+	return &Xts{data: data, tweak: tweak}, nil
+}
+
+func (x *Xts) EncryptSector(sectorNum uint64, data []byte) ([]byte, error) {
+	return x.crypt(sectorNum, data, true)
+}
+
+func (x *Xts) DecryptSector(sectorNum uint64, data []byte) ([]byte, error) {
+	return x.crypt(sectorNum, data, false)
+}
+
+func (x *Xts) crypt(sectorNum uint64, data []byte, encrypt bool) ([]byte, error) {
+	if len(data) < xtsBlockSize {
+		return nil, ErrSectorSize
+	}
+
+	var tweak [xtsBlockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:], sectorNum)
+	x.tweak.Encrypt(tweak[:], tweak[:])
+
+	dst := make([]byte, len(data))
+	out, src := dst, data
+	remain := len(data) % xtsBlockSize
+
+	// 需要密文窃取时保留最后一个完整分组
+	full := len(data) - remain
+	if remain != 0 {
+		full -= xtsBlockSize
+	}
+
+	for ; full > 0; full -= xtsBlockSize {
+		x.cryptBlock(out, src, &tweak, encrypt)
+		tweak = xtsDouble(tweak)
+		out, src = out[xtsBlockSize:], src[xtsBlockSize:]
+	}
+
+	if remain == 0 {
+		return dst, nil
+	}
+
+	first, second := tweak, xtsDouble(tweak)
+	if !encrypt {
+		first, second = second, first
+	}
+
+	var block [xtsBlockSize]byte
+	x.cryptBlock(block[:], src[:xtsBlockSize], &first, encrypt)
+	copy(out[xtsBlockSize:], block[:remain])
+	copy(block[:], src[xtsBlockSize:])
+	x.cryptBlock(out, block[:], &second, encrypt)
+	return dst, nil
+}
+
+func (x *Xts) cryptBlock(dst, src []byte, tweak *[xtsBlockSize]byte, encrypt bool) {
+	subtle.XORBytes(dst[:xtsBlockSize], src[:xtsBlockSize], tweak[:])
+	if encrypt {
+		x.data.Encrypt(dst, dst)
+	} else {
+		x.data.Decrypt(dst, dst)
+	}
+
+	subtle.XORBytes(dst[:xtsBlockSize], dst[:xtsBlockSize], tweak[:])
+}
+
+// xtsDouble 小端序的 GF(2^128) 乘 α
+func xtsDouble(in [xtsBlockSize]byte) (out [xtsBlockSize]byte) {
+	var carry byte
+	for i := 0; i < xtsBlockSize; i++ {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+
+	out[0] ^= byte(subtle.ConstantTimeByteEq(carry, 1)) * 0x87
+	return
+}
@@ -0,0 +1,72 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXtsVector(t *testing.T) {
+	// IEEE 1619 Vector 2 与 Vector 15 (密文窃取)
+	cases := []struct {
+		key       string
+		sectorNum uint64
+		plainText string
+		result    string
+	}{
+		{
+			"1111111111111111111111111111111122222222222222222222222222222222", 0x3333333333,
+			"4444444444444444444444444444444444444444444444444444444444444444",
+			"c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0",
+		},
+		{
+			"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+			"000102030405060708090a0b0c0d0e0f10",
+			"6c1625db4671522d3d7599601de7ca09ed",
+		},
+	}
+
+	for _, c := range cases {
+		xtsKey, _ := hex.DecodeString(c.key)
+		plainText, _ := hex.DecodeString(c.plainText)
+
+		xts := NewXts(xtsKey)
+		encrypted, err := xts.EncryptSector(c.sectorNum, plainText)
+		assert.NoError(t, err)
+		assert.Equal(t, c.result, hex.EncodeToString(encrypted))
+
+		decrypted, err := xts.DecryptSector(c.sectorNum, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, plainText, decrypted)
+	}
+}
+
+func TestXtsSectors(t *testing.T) {
+	xts := NewAes(append(append([]byte{}, key...), iv...), nil).XTS()
+	sector := []byte("1234567890abcdefghijklmnopqrstuvw")
+
+	first, err := xts.EncryptSector(1, sector)
+	assert.NoError(t, err)
+	assert.Len(t, first, len(sector))
+
+	second, err := xts.EncryptSector(2, sector)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	decrypted, err := xts.DecryptSector(2, second)
+	assert.NoError(t, err)
+	assert.Equal(t, sector, decrypted)
+
+	_, err = xts.EncryptSector(1, sector[:15])
+	assert.ErrorIs(t, err, ErrSectorSize)
+}
+
+func TestXtsDuplicateKey(t *testing.T) {
+	// IEEE 1619 Vector 1 的两半 key 相同, 应当拒绝
+	_, err := TryNewXts(make([]byte, 32))
+	assert.ErrorIs(t, err, ErrXtsDuplicateKey)
+
+	_, err = NewAes(append(append([]byte{}, key...), key...), nil).TryXTS()
+	assert.ErrorIs(t, err, ErrXtsDuplicateKey)
+}